@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nickeskov/netmon/pkg/monitor"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const backtestCommand = "backtest"
+
+type backtestConfig struct {
+	recordsFile      string
+	candidatesFile   string
+	networkScheme    string
+	statsHistorySize int
+	streak           int
+	format           string
+}
+
+func (c *backtestConfig) parse(l *zap.SugaredLogger, args []string) error {
+	fs := flag.NewFlagSet(backtestCommand, flag.ExitOnError)
+	fs.StringVar(&c.recordsFile, "records", "", "Path to the nodes stats record file created with 'stats-record-file' option. REQUIRED.")
+	fs.StringVar(&c.candidatesFile, "candidates", "", "Path to the JSON file with an array of candidates: [{\"name\": \"...\", \"streak\": 5, \"criteria\": {...}}]. The first candidate is the baseline. REQUIRED.")
+	fs.StringVar(&c.networkScheme, "network-scheme", lookupEnvOrString("NETWORK_SCHEME", "W"), "WAVES network scheme character. ENV: 'NETWORK_SCHEME'.")
+	fs.IntVar(&c.statsHistorySize, "stats-history-size", lookupEnvOrInt(l, "STATS_HISTORY_SIZE", 10), "Exact amount of latest nodes stats that will be kept. ENV: 'STATS_HISTORY_SIZE'.")
+	fs.IntVar(&c.streak, "network-errors-streak", lookupEnvOrInt(l, "NETWORK_ERRORS_STREAK", 5), "Errors streak for candidates which don't set it explicitly. ENV: 'NETWORK_ERRORS_STREAK'.")
+	fs.StringVar(&c.format, "format", "text", "Report format. Supported formats: 'text', 'json'.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if c.recordsFile == "" {
+		return errors.New("please, provide 'records' parameter")
+	}
+	if c.candidatesFile == "" {
+		return errors.New("please, provide 'candidates' parameter")
+	}
+	if c.format != "text" && c.format != "json" {
+		return errors.Errorf("unsupported report format %q", c.format)
+	}
+	return nil
+}
+
+func runBacktest(l *zap.SugaredLogger, args []string, out io.Writer) error {
+	config := backtestConfig{}
+	if err := config.parse(l, args); err != nil {
+		return err
+	}
+
+	records, err := readBacktestRecords(config.recordsFile)
+	if err != nil {
+		return err
+	}
+	candidates, err := readBacktestCandidates(config.candidatesFile, config.streak)
+	if err != nil {
+		return err
+	}
+
+	reports, err := monitor.Backtest(
+		records,
+		monitor.NetworkSchemeChar(config.networkScheme),
+		config.statsHistorySize,
+		candidates,
+	)
+	if err != nil {
+		return err
+	}
+
+	if config.format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+	return writeBacktestReportsText(out, records, reports)
+}
+
+func readBacktestRecords(path string) ([]monitor.StatsRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open records file")
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			zap.S().Errorf("failed to close records file: %v", err)
+		}
+	}()
+	return monitor.ReadStatsRecords(f)
+}
+
+func readBacktestCandidates(path string, defaultStreak int) ([]monitor.BacktestCandidate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read candidates file")
+	}
+	var candidates []monitor.BacktestCandidate
+	if err := json.Unmarshal(data, &candidates); err != nil {
+		return nil, errors.Wrap(err, "failed to parse candidates file")
+	}
+	for i := range candidates {
+		if candidates[i].Name == "" {
+			candidates[i].Name = fmt.Sprintf("candidate-%d", i)
+		}
+		if candidates[i].AlertOnNetworkErrorStreak == 0 {
+			candidates[i].AlertOnNetworkErrorStreak = defaultStreak
+		}
+	}
+	return candidates, nil
+}
+
+func writeBacktestReportsText(out io.Writer, records []monitor.StatsRecord, reports []monitor.BacktestReport) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Records: %d, from %s to %s\n\n",
+		len(records),
+		records[0].Timestamp.Format(time.RFC3339),
+		records[len(records)-1].Timestamp.Format(time.RFC3339),
+	)
+	_, _ = fmt.Fprintln(w, "CANDIDATE\tSTREAK\tINCIDENTS\tTOTAL DEGRADED\tDIFFS FROM BASELINE")
+	for _, r := range reports {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%d\n",
+			r.Candidate.Name, r.Candidate.AlertOnNetworkErrorStreak, len(r.Incidents), r.TotalDegraded, len(r.BaselineDiffs),
+		)
+	}
+	for _, r := range reports {
+		_, _ = fmt.Fprintf(w, "\nCandidate %q incidents:\n", r.Candidate.Name)
+		_, _ = fmt.Fprintln(w, "DEGRADED\tRECOVERED\tDURATION")
+		for _, inc := range r.Incidents {
+			recovered := "-"
+			if !inc.Recovered.IsZero() {
+				recovered = inc.Recovered.Format(time.RFC3339)
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", inc.Degraded.Format(time.RFC3339), recovered, inc.Duration)
+		}
+		if len(r.BaselineDiffs) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "\nCandidate %q differences from baseline %q:\n", r.Candidate.Name, reports[0].Candidate.Name)
+		_, _ = fmt.Fprintln(w, "FROM\tTO\tBASELINE STATUS\tCANDIDATE STATUS")
+		for _, d := range r.BaselineDiffs {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%t\t%t\n",
+				d.From.Format(time.RFC3339), d.To.Format(time.RFC3339), d.BaselineStatus, d.CandidateStatus,
+			)
+		}
+	}
+	return w.Flush()
+}
@@ -4,31 +4,88 @@ import (
 	"flag"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/nickeskov/netmon/pkg/common"
 	"github.com/nickeskov/netmon/pkg/monitor"
+	"github.com/nickeskov/netmon/pkg/service"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
 type appConfig struct {
 	logLevel               string
+	logFormat              string
+	logOutput              string
+	logFile                string
+	logFileMaxSize         int
+	logFileMaxBackups      int
+	logFileMaxAge          int
 	bindAddr               string
+	grpcBindAddr           string
 	networkScheme          string
 	nodeStatsURL           string
 	pollNodesStatsInterval time.Duration
 	maxPollResponseSize    int
+	maxPollResponseWarn    float64
 	statsHistorySize       int
+	maxIncidents           int
 	networkErrorsStreak    int
 	initialMonState        string
+	statsRecordFile        string
+
+	statsRequestTimeout  time.Duration
+	statsRetries         int
+	statsRetryBackoff    time.Duration
+	statsRetryMaxBackoff time.Duration
+	statsUserAgent       string
+	statsProxyURL        string
+	statsTLSCAFile       string
+
+	nodesInclude string
+	nodesExclude string
+
+	blockRateWindows string
+
+	slaTransitionsFile string
+
+	healthStatusCodes  string
+	healthMaxStaleness time.Duration
+	livezTimeout       time.Duration
 
 	httpAuthHeader string
 	httpAuthToken  string
 
-	criterionNodesDownTotalPart float64
+	criterionNodesDownTotalPart      float64
+	criterionNodesDownMalformedNodes string
+
+	criterionNodesSyncingTotalPart float64
+
+	criterionRequiredNodes             string
+	criterionRequiredNodesMaxHeightLag int
+
+	nodeWeights   string
+	severityRules string
 
 	criterionNodesHeightDiff                    int
 	criterionNodesHeightRequireMinNodesOnHeight int
 
+	criterionNodesHeightLagMaxLag              int
+	criterionNodesHeightLagMaxLaggingNodes     int
+	criterionNodesHeightLagMaxLaggingNodesPart float64
+
+	criterionNodesFlappingMaxTransitions   int
+	criterionNodesFlappingMaxFlappingNodes int
+
+	criterionBlockIntervalExpected     time.Duration
+	criterionBlockIntervalMaxDeviation float64
+	criterionBlockIntervalWindow       time.Duration
+
+	criterionAnomalySigma  float64
+	criterionAnomalyAlpha  float64
+	criterionAnomalyWarmUp int
+
 	criterionNodesStateHashMinStateHashGroupsOnSameHeight   int
 	criterionNodesStateHashMinValuableStateHashGroups       int
 	criterionNodesStateHashMinNodesInValuableStateHashGroup int
@@ -37,23 +94,75 @@ type appConfig struct {
 
 func (c *appConfig) parseENVAndRegisterCLI(l *zap.SugaredLogger) {
 	flag.StringVar(&c.logLevel, "log-level", lookupEnvOrString("LOG_LEVEL", "INFO"), "Logging level. Supported levels: 'DEV', 'DEBUG', 'INFO', 'WARN', 'ERROR', 'FATAL'. ENV: 'LOG_LEVEL'.")
+	flag.StringVar(&c.logFormat, "log-format", lookupEnvOrString("LOG_FORMAT", string(common.LogFormatConsole)), "Logging format. Supported formats: 'console', 'json'. ENV: 'LOG_FORMAT'.")
+	flag.StringVar(&c.logOutput, "log-output", lookupEnvOrString("LOG_OUTPUT", string(common.LogOutputStdout)), "Logging output. Supported outputs: 'stdout', 'stderr', 'file'. ENV: 'LOG_OUTPUT'.")
+	flag.StringVar(&c.logFile, "log-file", lookupEnvOrString("LOG_FILE", ""), "Path to the log file for the 'file' logging output. ENV: 'LOG_FILE'.")
+	flag.IntVar(&c.logFileMaxSize, "log-file-max-size", lookupEnvOrInt(l, "LOG_FILE_MAX_SIZE", 100), "Log file is rotated when it reaches that size in megabytes. ENV: 'LOG_FILE_MAX_SIZE'.")
+	flag.IntVar(&c.logFileMaxBackups, "log-file-max-backups", lookupEnvOrInt(l, "LOG_FILE_MAX_BACKUPS", 0), "Max amount of rotated log files to keep. Zero value keeps all of them. ENV: 'LOG_FILE_MAX_BACKUPS'.")
+	flag.IntVar(&c.logFileMaxAge, "log-file-max-age", lookupEnvOrInt(l, "LOG_FILE_MAX_AGE", 0), "Max amount of days to keep rotated log files. Zero value keeps them regardless of age. ENV: 'LOG_FILE_MAX_AGE'.")
 	flag.StringVar(&c.bindAddr, "bind-addr", lookupEnvOrString("BIND_ADDR", ":2048"), "Local network address to bind the HTTP API of the service on. ENV: 'BIND_ADDR'.")
+	flag.StringVar(&c.grpcBindAddr, "grpc-bind-addr", lookupEnvOrString("GRPC_BIND_ADDR", ""), "Local network address to bind the gRPC API of the service on. Empty value disables the gRPC API. ENV: 'GRPC_BIND_ADDR'.")
 	flag.StringVar(&c.networkScheme, "network-scheme", lookupEnvOrString("NETWORK_SCHEME", "W"), "WAVES network scheme character. Supported networks: 'W' (mainnet), 'T' (testnet), 'S' (stagenet). ENV: 'NETWORK_SCHEME'.")
 	flag.StringVar(&c.nodeStatsURL, "stats-url", lookupEnvOrString("STATS_URL", "https://waves-nodes-get-height.wavesnodes.com/"), "Nodes statistics URL. ENV: 'STATS_URL'.")
 	flag.DurationVar(&c.pollNodesStatsInterval, "stats-poll-interval", lookupEnvOrDuration(l, "STATS_POLL_INTERVAL", time.Minute), "Nodes statistics polling interval. ENV: 'STATS_POLL_INTERVAL'.")
 	flag.IntVar(&c.maxPollResponseSize, "max-poll-response-size", lookupEnvOrInt(l, "MAX_POLL_RESPONSE_SIZE", monitor.DefaultNodeStatsPollResponseSize), "Max nodes stats poll response size in bytes. ENV: 'MAX_POLL_RESPONSE_SIZE'.")
+	flag.Float64Var(&c.maxPollResponseWarn, "max-poll-response-size-warn-ratio", lookupEnvOrFloat64(l, "MAX_POLL_RESPONSE_SIZE_WARN_RATIO", 0), "Warning will be logged if nodes stats poll response size reaches that part of 'max-poll-response-size'. Zero value disables warnings. ENV: 'MAX_POLL_RESPONSE_SIZE_WARN_RATIO'.")
 	flag.IntVar(&c.statsHistorySize, "stats-history-size", lookupEnvOrInt(l, "STATS_HISTORY_SIZE", 10), "Exact amount of latest nodes stats that will be kept. ENV: 'STATS_HISTORY_SIZE'.")
+	flag.IntVar(&c.maxIncidents, "max-incidents", lookupEnvOrInt(l, "MAX_INCIDENTS", monitor.DefaultMaxIncidents), "Amount of latest incidents that will be kept, the oldest incident is dropped when the limit is reached. ENV: 'MAX_INCIDENTS'.")
 	flag.IntVar(&c.networkErrorsStreak, "network-errors-streak", lookupEnvOrInt(l, "NETWORK_ERRORS_STREAK", 5), "Network will be considered as degraded after that errors streak. ENV: 'NETWORK_ERRORS_STREAK'.")
 	flag.StringVar(&c.initialMonState, "initial-mon-state", lookupEnvOrString("INITIAL_MON_STATE", "active"), "Initial monitoring state. Possible states: 'active', 'frozen_operates_stable', 'frozen_degraded'. ENV: 'INITIAL_MON_STATE'.")
+	flag.StringVar(&c.statsRecordFile, "stats-record-file", lookupEnvOrString("STATS_RECORD_FILE", ""), "Path to the file to which every scraped nodes statistics payload will be appended. Recording is disabled if empty. ENV: 'STATS_RECORD_FILE'.")
+
+	flag.DurationVar(&c.statsRequestTimeout, "stats-request-timeout", lookupEnvOrDuration(l, "STATS_REQUEST_TIMEOUT", monitor.DefaultNodeStatsRequestTimeout), "Timeout of a single nodes statistics request attempt. Zero value disables the timeout. ENV: 'STATS_REQUEST_TIMEOUT'.")
+	flag.IntVar(&c.statsRetries, "stats-retries", lookupEnvOrInt(l, "STATS_RETRIES", 2), "Amount of nodes statistics request retries on network errors and HTTP 5xx responses. ENV: 'STATS_RETRIES'.")
+	flag.DurationVar(&c.statsRetryBackoff, "stats-retry-backoff", lookupEnvOrDuration(l, "STATS_RETRY_BACKOFF", monitor.DefaultNodeStatsRetryBackoff), "Initial delay between nodes statistics request retries, it's doubled after each retry and jittered. ENV: 'STATS_RETRY_BACKOFF'.")
+	flag.DurationVar(&c.statsRetryMaxBackoff, "stats-retry-max-backoff", lookupEnvOrDuration(l, "STATS_RETRY_MAX_BACKOFF", monitor.DefaultNodeStatsRetryMaxBackoff), "Max delay between nodes statistics request retries. ENV: 'STATS_RETRY_MAX_BACKOFF'.")
+	flag.StringVar(&c.statsUserAgent, "stats-user-agent", lookupEnvOrString("STATS_USER_AGENT", monitor.DefaultNodeStatsUserAgent), "User-Agent header of nodes statistics requests. ENV: 'STATS_USER_AGENT'.")
+	flag.StringVar(&c.statsProxyURL, "stats-proxy-url", lookupEnvOrString("STATS_PROXY_URL", ""), "Proxy URL for nodes statistics requests. Proxy from HTTP_PROXY/HTTPS_PROXY env is used if empty. ENV: 'STATS_PROXY_URL'.")
+	flag.StringVar(&c.statsTLSCAFile, "stats-tls-ca-file", lookupEnvOrString("STATS_TLS_CA_FILE", ""), "Path to the PEM file with TLS root certificates for nodes statistics requests. System roots are used if empty. ENV: 'STATS_TLS_CA_FILE'.")
+
+	flag.StringVar(&c.nodesInclude, "nodes-include", lookupEnvOrString("NODES_INCLUDE", ""), "Comma separated list of domain glob patterns, only matching nodes will be monitored. All nodes are monitored if empty. ENV: 'NODES_INCLUDE'.")
+	flag.StringVar(&c.nodesExclude, "nodes-exclude", lookupEnvOrString("NODES_EXCLUDE", ""), "Comma separated list of domain glob patterns, matching nodes won't be monitored. Takes precedence over 'nodes-include'. ENV: 'NODES_EXCLUDE'.")
+	flag.StringVar(&c.blockRateWindows, "block-rate-windows", lookupEnvOrString("BLOCK_RATE_WINDOWS", "5m,10m"), "Comma separated list of windows over which block rates are reported. Windows are limited by 'stats-history-size' snapshots. ENV: 'BLOCK_RATE_WINDOWS'.")
+	flag.StringVar(&c.slaTransitionsFile, "sla-transitions-file", lookupEnvOrString("SLA_TRANSITIONS_FILE", ""), "Path to the file in which network status transitions are persisted for SLA reports. Transitions are kept only in memory if empty. ENV: 'SLA_TRANSITIONS_FILE'.")
 
-	flag.StringVar(&c.httpAuthHeader, "http-auth-header", lookupEnvOrString("HTTP_AUTH_HEADER", "X-Waves-Monitor-Auth"), "HTTP header which will be used for private routes authentication. ENV: 'HTTP_AUTH_HEADER'.")
+	flag.StringVar(&c.healthStatusCodes, "health-status-codes", lookupEnvOrString("HEALTH_STATUS_CODES", ""), "Comma separated list of 'severity=code' pairs which override HTTP status codes of '/health/status' endpoint. Keys are 'ok', 'warning', 'degraded', 'critical' and 'stale'. By default degraded, critical and stale statuses are answered with 503. ENV: 'HEALTH_STATUS_CODES'.")
+	flag.DurationVar(&c.healthMaxStaleness, "health-max-staleness", lookupEnvOrDuration(l, "HEALTH_MAX_STALENESS", 5*time.Minute), "Network status of '/health/status' endpoint is stale if the latest statistics are older than that value. Zero value disables the staleness check. ENV: 'HEALTH_MAX_STALENESS'.")
+
+	flag.DurationVar(&c.livezTimeout, "livez-timeout", lookupEnvOrDuration(l, "LIVEZ_TIMEOUT", service.DefaultLivenessTimeout), "Netmon isn't alive if a scrape lasts longer than that value or the monitor loop hasn't iterated for 'stats-poll-interval' plus that value. ENV: 'LIVEZ_TIMEOUT'.")
+
+	flag.StringVar(&c.httpAuthHeader, "http-auth-header", lookupEnvOrString("HTTP_AUTH_HEADER", service.DefaultAuthHeader), "HTTP header which will be used for private routes authentication. ENV: 'HTTP_AUTH_HEADER'.")
 	flag.StringVar(&c.httpAuthToken, "http-auth-token", lookupEnvOrString("HTTP_AUTH_TOKEN", ""), "HTTP auth token which will be used for private routes authentication. ENV: 'HTTP_AUTH_TOKEN'.")
 
 	flag.Float64Var(&c.criterionNodesDownTotalPart, "criterion-down-total-part", lookupEnvOrFloat64(l, "CRITERION_DOWN_TOTAL_PART", 0.3), "Alert will be generated if detected down nodes part greater than that criterion. ENV: 'CRITERION_DOWN_TOTAL_PART'.")
+	flag.StringVar(&c.criterionNodesDownMalformedNodes, "criterion-down-malformed-nodes", lookupEnvOrString("CRITERION_DOWN_MALFORMED_NODES", string(monitor.MalformedNodesIgnore)), "How malformed nodes are treated by down nodes criterion. Possible values: 'ignore' (treated as up nodes, counted only in total nodes amount), 'down' (counted as down nodes), 'exclude' (excluded from total nodes amount). ENV: 'CRITERION_DOWN_MALFORMED_NODES'.")
+
+	flag.Float64Var(&c.criterionNodesSyncingTotalPart, "criterion-syncing-total-part", lookupEnvOrFloat64(l, "CRITERION_SYNCING_TOTAL_PART", 0), "Alert will be generated if detected syncing (zero height) nodes part greater than that criterion. Zero value disables the criterion. ENV: 'CRITERION_SYNCING_TOTAL_PART'.")
+
+	flag.StringVar(&c.criterionRequiredNodes, "criterion-required-nodes", lookupEnvOrString("CRITERION_REQUIRED_NODES", ""), "Comma separated list of required nodes domains. Alert will be generated if any of them is down, missing or lags behind the max height. Empty value disables the criterion. ENV: 'CRITERION_REQUIRED_NODES'.")
+	flag.IntVar(&c.criterionRequiredNodesMaxHeightLag, "criterion-required-nodes-max-height-lag", lookupEnvOrInt(l, "CRITERION_REQUIRED_NODES_MAX_HEIGHT_LAG", 5), "Required node will be considered as lagging if its height is behind the max height more than that criterion. ENV: 'CRITERION_REQUIRED_NODES_MAX_HEIGHT_LAG'.")
+
+	flag.StringVar(&c.severityRules, "severity-rules", lookupEnvOrString("SEVERITY_RULES", ""), "Comma separated list of 'criterion=severity' or 'criterion=severity:duration:severity' rules which override default severity of the alerted criteria. The second severity is used if the criterion has been alerted for the duration. Severities are 'ok', 'warning', 'degraded' and 'critical'. ENV: 'SEVERITY_RULES'.")
+	flag.StringVar(&c.nodeWeights, "node-weights", lookupEnvOrString("NODE_WEIGHTS", ""), "Comma separated list of 'domain=weight' pairs. Down nodes and statehash criteria use summed weights of nodes instead of their amount. Nodes which aren't listed have weight 1. ENV: 'NODE_WEIGHTS'.")
 
 	flag.IntVar(&c.criterionNodesHeightDiff, "criterion-height-diff", lookupEnvOrInt(l, "CRITERION_HEIGHT_DIFF", 5), "Alert will be generated if detected height diff greater than that criterion. ENV: 'CRITERION_HEIGHT_DIFF'.")
 	flag.IntVar(&c.criterionNodesHeightRequireMinNodesOnHeight, "criterion-height-require-min-nodes-on-same-height", lookupEnvOrInt(l, "CRITERION_HEIGHT_REQUIRE_MIN_NODES_ON_SAME_HEIGHT", 2), "Minimum required amount of nodes on same height for height-diff criterion. ENV: 'CRITERION_HEIGHT_REQUIRE_MIN_NODES_ON_SAME_HEIGHT'.")
 
+	flag.IntVar(&c.criterionNodesHeightLagMaxLag, "criterion-height-lag-max-lag", lookupEnvOrInt(l, "CRITERION_HEIGHT_LAG_MAX_LAG", 0), "Node will be considered as lagging if it's behind the max height more than that criterion. Zero value disables the height lag criterion. ENV: 'CRITERION_HEIGHT_LAG_MAX_LAG'.")
+	flag.IntVar(&c.criterionNodesHeightLagMaxLaggingNodes, "criterion-height-lag-max-lagging-nodes", lookupEnvOrInt(l, "CRITERION_HEIGHT_LAG_MAX_LAGGING_NODES", 0), "Alert will be generated if amount of lagging nodes greater than that criterion. Zero value disables the check, if 'criterion-height-lag-max-lagging-part' is zero too, any lagging node generates alert. ENV: 'CRITERION_HEIGHT_LAG_MAX_LAGGING_NODES'.")
+	flag.Float64Var(&c.criterionNodesHeightLagMaxLaggingNodesPart, "criterion-height-lag-max-lagging-part", lookupEnvOrFloat64(l, "CRITERION_HEIGHT_LAG_MAX_LAGGING_PART", 0), "Alert will be generated if lagging nodes part among working nodes greater than that criterion. Zero value disables the check. ENV: 'CRITERION_HEIGHT_LAG_MAX_LAGGING_PART'.")
+
+	flag.IntVar(&c.criterionNodesFlappingMaxTransitions, "criterion-flapping-max-transitions", lookupEnvOrInt(l, "CRITERION_FLAPPING_MAX_TRANSITIONS", 0), "Node will be considered as flapping if it has changed between working and down states more than that criterion within 'stats-history-size' snapshots. Zero value disables the flapping criterion. ENV: 'CRITERION_FLAPPING_MAX_TRANSITIONS'.")
+	flag.IntVar(&c.criterionNodesFlappingMaxFlappingNodes, "criterion-flapping-max-nodes", lookupEnvOrInt(l, "CRITERION_FLAPPING_MAX_NODES", 0), "Alert will be generated if amount of flapping nodes greater than that criterion. ENV: 'CRITERION_FLAPPING_MAX_NODES'.")
+
+	flag.DurationVar(&c.criterionBlockIntervalExpected, "criterion-block-interval-expected", lookupEnvOrDuration(l, "CRITERION_BLOCK_INTERVAL_EXPECTED", monitor.DefaultExpectedBlockInterval), "Expected average block interval of the network. ENV: 'CRITERION_BLOCK_INTERVAL_EXPECTED'.")
+	flag.Float64Var(&c.criterionBlockIntervalMaxDeviation, "criterion-block-interval-max-deviation", lookupEnvOrFloat64(l, "CRITERION_BLOCK_INTERVAL_MAX_DEVIATION", 0), "Alert will be generated if average block interval deviates from expected one by more than that part. Zero value disables the block interval criterion. ENV: 'CRITERION_BLOCK_INTERVAL_MAX_DEVIATION'.")
+	flag.DurationVar(&c.criterionBlockIntervalWindow, "criterion-block-interval-window", lookupEnvOrDuration(l, "CRITERION_BLOCK_INTERVAL_WINDOW", 5*time.Minute), "Window over which average block interval is calculated for block interval criterion. It should be covered by 'stats-history-size' snapshots. ENV: 'CRITERION_BLOCK_INTERVAL_WINDOW'.")
+
+	flag.Float64Var(&c.criterionAnomalySigma, "criterion-anomaly-sigma", lookupEnvOrFloat64(l, "CRITERION_ANOMALY_SIGMA", 0), "Alert will be generated if any network indicator grows above its learned mean by more than that amount of standard deviations. Zero value disables the anomaly criterion. ENV: 'CRITERION_ANOMALY_SIGMA'.")
+	flag.Float64Var(&c.criterionAnomalyAlpha, "criterion-anomaly-alpha", lookupEnvOrFloat64(l, "CRITERION_ANOMALY_ALPHA", 0.05), "Smoothing factor of network indicators moving averages for anomaly criterion. ENV: 'CRITERION_ANOMALY_ALPHA'.")
+	flag.IntVar(&c.criterionAnomalyWarmUp, "criterion-anomaly-warm-up", lookupEnvOrInt(l, "CRITERION_ANOMALY_WARM_UP", 60), "Amount of stats snapshots from which network indicators baselines are learned before anomaly criterion can be generated. ENV: 'CRITERION_ANOMALY_WARM_UP'.")
+
 	flag.IntVar(&c.criterionNodesStateHashMinStateHashGroupsOnSameHeight, "criterion-statehash-min-groups-on-same-height", lookupEnvOrInt(l, "CRITERION_STATEHASH_MIN_GROUPS_ON_SAME_HEIGHT", 2), "Alert won't be generated if detected amount of statehash groups on same height lower than that criterion. ENV: 'CRITERION_STATEHASH_MIN_GROUPS_ON_SAME_HEIGHT'.")
 	flag.IntVar(&c.criterionNodesStateHashMinValuableStateHashGroups, "criterion-statehash-min-valuable-groups", lookupEnvOrInt(l, "CRITERION_STATEHASH_MIN_VALUABLE_GROUPS", 2), "Alert won't be generated if detected amount of statehash 'valuable' groups on same height lower than that criterion. ENV: 'CRITERION_STATEHASH_MIN_VALUABLE_GROUPS'.")
 	flag.IntVar(&c.criterionNodesStateHashMinNodesInValuableStateHashGroup, "criterion-statehash-min-nodes-in-valuable-group", lookupEnvOrInt(l, "CRITERION_STATEHASH_MIN_NODES_IN_VALUABLE_GROUP", 2), "StateHash group will be considered as 'valuable' if contains 'criterion-statehash-min-valuable-groups'. ENV: 'CRITERION_STATEHASH_MIN_NODES_IN_VALUABLE_GROUP'.")
@@ -69,6 +178,125 @@ func (c *appConfig) registerAndParseAll(l *zap.SugaredLogger) {
 	c.parseCLI()
 }
 
+// splitList splits comma separated list and drops empty items.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseDurations parses comma separated list of durations.
+func parseDurations(list string) ([]time.Duration, error) {
+	var durations []time.Duration
+	for _, item := range splitList(list) {
+		d, err := time.ParseDuration(item)
+		if err != nil {
+			return nil, err
+		}
+		durations = append(durations, d)
+	}
+	return durations, nil
+}
+
+// parseNodeWeights parses comma separated list of 'domain=weight' pairs.
+func parseNodeWeights(list string) (map[string]float64, error) {
+	items := splitList(list)
+	if len(items) == 0 {
+		return nil, nil
+	}
+	weights := make(map[string]float64, len(items))
+	for _, item := range items {
+		domain, weightStr, ok := strings.Cut(item, "=")
+		domain = strings.TrimSpace(domain)
+		if !ok || domain == "" {
+			return nil, errors.Errorf("invalid node weight %q, expected 'domain=weight'", item)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(weightStr), 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid weight of node %q", domain)
+		}
+		weights[domain] = weight
+	}
+	return weights, nil
+}
+
+// parseSeverityRules parses comma separated list of 'criterion=severity' or 'criterion=severity:duration:severity'
+// rules.
+func parseSeverityRules(list string) (monitor.SeverityRules, error) {
+	items := splitList(list)
+	if len(items) == 0 {
+		return nil, nil
+	}
+	rules := make(monitor.SeverityRules, len(items))
+	for _, item := range items {
+		criterion, ruleStr, ok := strings.Cut(item, "=")
+		criterion = strings.TrimSpace(criterion)
+		if !ok || criterion == "" {
+			return nil, errors.Errorf("invalid severity rule %q, expected 'criterion=severity[:duration:severity]'", item)
+		}
+		parts := strings.Split(ruleStr, ":")
+		if len(parts) != 1 && len(parts) != 3 {
+			return nil, errors.Errorf("invalid severity rule %q, expected 'criterion=severity[:duration:severity]'", item)
+		}
+		var (
+			rule monitor.SeverityRule
+			err  error
+		)
+		if rule.Severity, err = monitor.NewSeverityFromString(strings.TrimSpace(parts[0])); err != nil {
+			return nil, errors.Wrapf(err, "invalid severity rule of criterion %q", criterion)
+		}
+		if len(parts) == 3 {
+			if rule.EscalateAfter, err = time.ParseDuration(strings.TrimSpace(parts[1])); err != nil {
+				return nil, errors.Wrapf(err, "invalid severity rule of criterion %q", criterion)
+			}
+			if rule.EscalateTo, err = monitor.NewSeverityFromString(strings.TrimSpace(parts[2])); err != nil {
+				return nil, errors.Wrapf(err, "invalid severity rule of criterion %q", criterion)
+			}
+		}
+		rules[criterion] = rule
+	}
+	return rules, nil
+}
+
+// parseHealthStatusCodes parses comma separated list of 'severity=code' pairs, where severity may be 'stale' too.
+// Codes which aren't listed are taken from service.DefaultHealthStatusCodes.
+func parseHealthStatusCodes(list string) (service.HealthStatusCodes, error) {
+	codes := service.DefaultHealthStatusCodes()
+	for _, item := range splitList(list) {
+		key, codeStr, ok := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return service.HealthStatusCodes{}, errors.Errorf("invalid health status code %q, expected 'severity=code'", item)
+		}
+		code, err := strconv.Atoi(strings.TrimSpace(codeStr))
+		if err != nil {
+			return service.HealthStatusCodes{}, errors.Wrapf(err, "invalid health status code of %q", key)
+		}
+		switch key {
+		case monitor.SeverityOK.String():
+			codes.OK = code
+		case monitor.SeverityWarning.String():
+			codes.Warning = code
+		case monitor.SeverityDegraded.String():
+			codes.Degraded = code
+		case monitor.SeverityCritical.String():
+			codes.Critical = code
+		case "stale":
+			codes.Stale = code
+		default:
+			return service.HealthStatusCodes{}, errors.Errorf("unknown health status %q", key)
+		}
+	}
+	if err := codes.Validate(); err != nil {
+		return service.HealthStatusCodes{}, err
+	}
+	return codes, nil
+}
+
 func lookupEnvOrString(envKey string, defaultVal string) string {
 	if val, ok := os.LookupEnv(envKey); ok {
 		return val
@@ -2,13 +2,17 @@ package main
 
 import (
 	"context"
+	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/nickeskov/netmon/pkg/clock"
 	"github.com/nickeskov/netmon/pkg/common"
+	"github.com/nickeskov/netmon/pkg/grpcservice"
 	"github.com/nickeskov/netmon/pkg/monitor"
 	"github.com/nickeskov/netmon/pkg/service"
 	"github.com/nickeskov/netmon/pkg/service/middleware"
@@ -17,21 +21,68 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == backtestCommand {
+		_, s := common.SetupLogger(lookupEnvOrString("LOG_LEVEL", "WARN"))
+		if err := runBacktest(s, os.Args[2:], os.Stdout); err != nil {
+			s.Fatalf("backtest failed: %v", err)
+		}
+		return
+	}
+
 	config := appConfig{}
 	// setup logger for config parsing
 	_, s := common.SetupLogger("INFO")
 	config.registerAndParseAll(s)
 	// setup logger again for further usage
-	_, _ = common.SetupLogger(config.logLevel)
+	logFormat, err := common.NewLogFormatFromString(config.logFormat)
+	if err != nil {
+		s.Fatalf("invalid 'log-format' parameter: %v", err)
+	}
+	logOutput, closeLogOutput, err := common.NewLogWriter(config.logOutput, common.LogFile{
+		Path:       config.logFile,
+		MaxSizeMB:  config.logFileMaxSize,
+		MaxBackups: config.logFileMaxBackups,
+		MaxAgeDays: config.logFileMaxAge,
+	})
+	if err != nil {
+		s.Fatalf("invalid logging output: %v", err)
+	}
+	// main exits on fatal entries without running deferred functions, so the output is closed by the fatal hook too
+	logger, _ := common.SetupLogger(config.logLevel,
+		common.WithLogFormat(logFormat),
+		common.WithLogOutput(logOutput),
+		common.WithOnFatal(func() {
+			_ = logOutput.Sync()
+			_ = closeLogOutput()
+		}),
+	)
+	defer func() {
+		_ = logger.Sync()
+		if err := closeLogOutput(); err != nil {
+			s.Errorf("failed to close logging output: %v", err)
+		}
+	}()
 	zap.S().Info("starting server...")
 
 	// basic validations
 	if config.statsHistorySize < 1 {
 		zap.S().Fatal("'stats-history-size' parameter should be greater than zero")
 	}
+	if config.maxIncidents < 1 {
+		zap.S().Fatal("'max-incidents' parameter should be greater than zero")
+	}
 	if config.maxPollResponseSize < 1 {
 		zap.S().Fatal("'max-poll-response-size' parameter should be greater than zero")
 	}
+	if config.maxPollResponseWarn < 0 || config.maxPollResponseWarn > 1 {
+		zap.S().Fatal("'max-poll-response-size-warn-ratio' parameter should be 0.0 <= n <= 1.0")
+	}
+	if config.statsRequestTimeout < 0 {
+		zap.S().Fatal("'stats-request-timeout' parameter should be non negative")
+	}
+	if config.statsRetries < 0 {
+		zap.S().Fatal("'stats-retries' parameter should be non negative")
+	}
 	initialState, err := monitor.NewNetworkMonitoringStateFromString(config.initialMonState)
 	if err != nil {
 		zap.S().Fatalf("invalid monitoring initial state %q", initialState.String())
@@ -43,32 +94,159 @@ func main() {
 		zap.S().Fatal("please, provide 'http-auth-token' parameter")
 	}
 
+	historySpan := time.Duration(config.statsHistorySize-1) * config.pollNodesStatsInterval
+	if config.criterionBlockIntervalMaxDeviation != 0 && config.criterionBlockIntervalWindow > historySpan {
+		zap.S().Fatalf("'criterion-block-interval-window' parameter %s isn't covered by stats history span %s",
+			config.criterionBlockIntervalWindow, historySpan,
+		)
+	}
+	blockRateWindows, err := parseDurations(config.blockRateWindows)
+	if err != nil {
+		zap.S().Fatalf("invalid 'block-rate-windows' parameter: %v", err)
+	}
+	nodeWeights, err := parseNodeWeights(config.nodeWeights)
+	if err != nil {
+		zap.S().Fatalf("invalid 'node-weights' parameter: %v", err)
+	}
+
+	severityRules, err := parseSeverityRules(config.severityRules)
+	if err != nil {
+		zap.S().Fatalf("invalid 'severity-rules' parameter: %v", err)
+	}
+
+	healthStatusCodes, err := parseHealthStatusCodes(config.healthStatusCodes)
+	if err != nil {
+		zap.S().Fatalf("invalid 'health-status-codes' parameter: %v", err)
+	}
+
 	criteria := monitor.NetworkErrorCriteria{
 		NodesDown: monitor.NodesDownCriterion{
 			TotalDownNodesPart: config.criterionNodesDownTotalPart,
+			MalformedNodes:     monitor.MalformedNodesPolicy(config.criterionNodesDownMalformedNodes),
+		},
+		NodesSyncing: monitor.NodesSyncingCriterion{
+			TotalSyncingNodesPart: config.criterionNodesSyncingTotalPart,
 		},
 		NodesHeight: monitor.NodesHeightCriterion{
 			HeightDiff:              config.criterionNodesHeightDiff,
 			RequireMinNodesOnHeight: config.criterionNodesHeightRequireMinNodesOnHeight,
 		},
+		NodesLag: monitor.NodesHeightLagCriterion{
+			MaxLag:              config.criterionNodesHeightLagMaxLag,
+			MaxLaggingNodes:     config.criterionNodesHeightLagMaxLaggingNodes,
+			MaxLaggingNodesPart: config.criterionNodesHeightLagMaxLaggingNodesPart,
+		},
+		NodesFlapping: monitor.NodesFlappingCriterion{
+			MaxTransitions:   config.criterionNodesFlappingMaxTransitions,
+			MaxFlappingNodes: config.criterionNodesFlappingMaxFlappingNodes,
+		},
+		BlockInterval: monitor.BlockIntervalCriterion{
+			Expected:     config.criterionBlockIntervalExpected,
+			MaxDeviation: config.criterionBlockIntervalMaxDeviation,
+			Window:       config.criterionBlockIntervalWindow,
+		},
+		Anomaly: monitor.AnomalyCriterion{
+			Sigma:  config.criterionAnomalySigma,
+			Alpha:  config.criterionAnomalyAlpha,
+			WarmUp: config.criterionAnomalyWarmUp,
+		},
 		StateHash: monitor.NodesStateHashCriterion{
 			MinStateHashGroupsOnSameHeight:   config.criterionNodesStateHashMinStateHashGroupsOnSameHeight,
 			MinValuableStateHashGroups:       config.criterionNodesStateHashMinValuableStateHashGroups,
 			MinNodesInValuableStateHashGroup: config.criterionNodesStateHashMinNodesInValuableStateHashGroup,
 			RequireMinNodesOnHeight:          config.criterionNodesStateHashRequireMinNodesOnHeight,
 		},
+		RequiredNodes: monitor.RequiredNodesCriterion{
+			Nodes:        splitList(config.criterionRequiredNodes),
+			MaxHeightLag: config.criterionRequiredNodesMaxHeightLag,
+		},
+		NodeWeights: nodeWeights,
 	}
 	if err := criteria.Validate(); err != nil {
 		zap.S().Fatalf("invalid criteria: %v", err)
 	}
 
+	scraper, err := newNodesStatsScraper(&config, clock.Real())
+	if err != nil {
+		zap.S().Fatalf("failed to init nodes stats scraper: %v", err)
+	}
+	var (
+		scoresRecords io.Reader
+		statsRecorder *monitor.StatsRecorder
+	)
+	if config.statsRecordFile != "" {
+		recordFile, err := os.OpenFile(config.statsRecordFile, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			zap.S().Fatalf("failed to open stats record file: %v", err)
+		}
+		defer func() {
+			if err := recordFile.Close(); err != nil {
+				zap.S().Errorf("failed to close stats record file: %v", err)
+			}
+		}()
+		truncated, err := common.TruncatePartialLine(recordFile)
+		if err != nil {
+			zap.S().Fatalf("failed to repair stats record file: %v", err)
+		}
+		if truncated != 0 {
+			zap.S().Warnf("partially written stats record of %d bytes has been truncated in %q",
+				truncated, config.statsRecordFile,
+			)
+		}
+		// records are replayed into node scores while the monitor is created
+		scoresRecords = recordFile
+		statsRecorder = monitor.NewStatsRecorder(recordFile)
+		zap.S().Infof("scraped nodes stats will be recorded to %q", config.statsRecordFile)
+	}
+
+	transitionStore := monitor.NewTransitionStore(nil, nil)
+	lastSeenFile := ""
+	if config.slaTransitionsFile != "" {
+		transitionsFile, err := os.OpenFile(config.slaTransitionsFile, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			zap.S().Fatalf("failed to open status transitions file: %v", err)
+		}
+		defer func() {
+			if err := transitionsFile.Close(); err != nil {
+				zap.S().Errorf("failed to close status transitions file: %v", err)
+			}
+		}()
+		truncated, err := common.TruncatePartialLine(transitionsFile)
+		if err != nil {
+			zap.S().Fatalf("failed to repair status transitions file: %v", err)
+		}
+		if truncated != 0 {
+			zap.S().Warnf("partially written status transition of %d bytes has been truncated in %q",
+				truncated, config.slaTransitionsFile,
+			)
+		}
+		transitions, err := monitor.ReadStatusTransitions(transitionsFile)
+		if err != nil {
+			zap.S().Fatalf("failed to read status transitions file: %v", err)
+		}
+		transitionStore = monitor.NewTransitionStore(transitionsFile, transitions)
+		zap.S().Infof("%d status transitions have been loaded from %q", len(transitions), config.slaTransitionsFile)
+		lastSeenFile = config.slaTransitionsFile + ".lastseen"
+	}
+
 	mon, err := monitor.NewNetworkMonitoring(
 		initialState,
 		monitor.NetworkSchemeChar(config.networkScheme),
 		config.statsHistorySize,
-		monitor.NewNodesStatsScraperHTTP(config.nodeStatsURL, int64(config.maxPollResponseSize)),
+		scraper,
 		config.networkErrorsStreak,
 		criteria,
+		monitor.WithBlockRateWindows(blockRateWindows...),
+		monitor.WithTransitionStore(transitionStore),
+		monitor.WithLastSeenFile(lastSeenFile),
+		monitor.WithMaxIncidents(config.maxIncidents),
+		monitor.WithNodesScoresRecords(scoresRecords),
+		monitor.WithSeverityRules(severityRules),
+		monitor.WithStatsRecorder(statsRecorder),
+		monitor.WithNodesFilter(monitor.NodesFilter{
+			Include: splitList(config.nodesInclude),
+			Exclude: splitList(config.nodesExclude),
+		}),
 	)
 	if err != nil {
 		zap.S().Fatalf("failed to init monitor: %v", err)
@@ -93,18 +271,20 @@ func main() {
 			}
 		}()
 
-		monitoringService := service.NewNetworkMonitoringService(&mon)
+		monitoringService := service.NewNetworkMonitoringService(&mon,
+			service.WithHealthStatusCodes(healthStatusCodes),
+			service.WithHealthMaxStaleness(config.healthMaxStaleness),
+			service.WithLivenessTimeout(config.livezTimeout),
+			service.WithAuthHeader(config.httpAuthHeader),
+		)
 		authMiddleWare := middleware.NewHTTPAuthTokenMiddleware(config.httpAuthHeader, config.httpAuthToken)
 
-		// public URLs
-		http.HandleFunc("/health", monitoringService.NetworkHealth)
-		// private URLs
-		http.Handle("/state", authMiddleWare(http.HandlerFunc(monitoringService.SetMonitorState)))
+		router := service.NewRouter(&monitoringService, authMiddleWare)
 
 		// run monitor service
 		monitorDone := mon.RunInBackground(ctx, config.pollNodesStatsInterval)
 
-		server := http.Server{Addr: config.bindAddr, Handler: nil, ReadHeaderTimeout: time.Second, ReadTimeout: 10 * time.Second}
+		server := http.Server{Addr: config.bindAddr, Handler: router, ReadHeaderTimeout: time.Second, ReadTimeout: 10 * time.Second}
 		server.RegisterOnShutdown(func() {
 			// wait for monitor
 			<-monitorDone
@@ -128,6 +308,20 @@ func main() {
 		}
 	}()
 
+	grpcDone := make(chan error, 1)
+	if config.grpcBindAddr != "" {
+		grpcService := grpcservice.NewNetworkMonitoringService(&mon, config.httpAuthHeader, config.httpAuthToken)
+		go func() {
+			err := serveGRPC(ctx, config.grpcBindAddr, grpcService)
+			if err != nil {
+				zap.S().Errorf("gRPC server: %v", err)
+			}
+			grpcDone <- err
+		}()
+	} else {
+		grpcDone <- nil
+	}
+
 	gracefulStop := make(chan os.Signal, 1)
 	signal.Notify(gracefulStop,
 		os.Interrupt,
@@ -143,5 +337,26 @@ func main() {
 	if err := <-httpDone; err != nil {
 		zap.S().Fatalf("HTTP server error: %v", err)
 	}
+	if err := <-grpcDone; err != nil {
+		zap.S().Fatalf("gRPC server error: %v", err)
+	}
 	zap.S().Infof("server has been stopped successfully")
 }
+
+// serveGRPC serves the gRPC API until the context is done.
+func serveGRPC(ctx context.Context, addr string, svc *grpcservice.NetworkMonitoringService) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen gRPC address %q", addr)
+	}
+	srv := grpcservice.NewServer(ctx, svc)
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+	zap.S().Infof("gRPC API is served on %q", addr)
+	if err := srv.Serve(lis); err != nil {
+		return errors.Wrap(err, "gRPC Serve")
+	}
+	return nil
+}
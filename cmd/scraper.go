@@ -0,0 +1,51 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/nickeskov/netmon/pkg/clock"
+	"github.com/nickeskov/netmon/pkg/monitor"
+	"github.com/pkg/errors"
+)
+
+func newStatsHTTPClient(proxyURL, tlsCAFile string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid proxy URL %q", proxyURL)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+	if tlsCAFile != "" {
+		pem, err := os.ReadFile(tlsCAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read TLS CA file")
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no PEM certificates have been found in %q", tlsCAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+func newNodesStatsScraper(config *appConfig, clk clock.Clock) (monitor.NodesStatsScrapper, error) {
+	client, err := newStatsHTTPClient(config.statsProxyURL, config.statsTLSCAFile)
+	if err != nil {
+		return nil, err
+	}
+	return monitor.NewNodesStatsScraperHTTP(config.nodeStatsURL, int64(config.maxPollResponseSize),
+		monitor.WithHTTPClient(client),
+		monitor.WithRequestTimeout(config.statsRequestTimeout),
+		monitor.WithUserAgent(config.statsUserAgent),
+		monitor.WithRetries(config.statsRetries, config.statsRetryBackoff, config.statsRetryMaxBackoff),
+		monitor.WithResponseSizeWarnRatio(config.maxPollResponseWarn),
+		monitor.WithScraperClock(clk),
+	), nil
+}
@@ -0,0 +1,24 @@
+package clock
+
+import "time"
+
+// Clock provides the current time and timers. It allows to replace the wall clock in the monitor loop.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+// Real returns the clock which uses the standard time package.
+func Real() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
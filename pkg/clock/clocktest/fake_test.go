@@ -0,0 +1,46 @@
+package clocktest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2021, 12, 2, 19, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+	require.Equal(t, start, c.Now())
+
+	immediate := c.After(0)
+	require.Equal(t, start, <-immediate)
+
+	first := c.After(time.Minute)
+	second := c.After(2 * time.Minute)
+	require.Equal(t, 2, c.Waiters())
+
+	c.Advance(30 * time.Second)
+	require.Equal(t, start.Add(30*time.Second), c.Now())
+	require.Len(t, first, 0)
+	require.Equal(t, 2, c.Waiters())
+
+	c.Advance(30 * time.Second)
+	require.Equal(t, start.Add(time.Minute), <-first)
+	require.Len(t, second, 0)
+	require.Equal(t, 1, c.Waiters())
+
+	c.Set(start.Add(time.Hour))
+	require.Equal(t, start.Add(time.Hour), <-second)
+	require.Equal(t, 0, c.Waiters())
+}
+
+func TestFakeClock_BlockUntilWaiters(t *testing.T) {
+	c := NewFakeClock(time.Now())
+	fired := make(chan time.Time, 1)
+	go func() {
+		fired <- <-c.After(time.Second)
+	}()
+	c.BlockUntilWaiters(1)
+	c.Advance(time.Second)
+	require.Equal(t, c.Now(), <-fired)
+}
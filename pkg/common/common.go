@@ -8,7 +8,52 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-func SetupLogger(level string) (*zap.Logger, *zap.SugaredLogger) {
+type LoggerOption func(o *loggerOptions)
+
+type loggerOptions struct {
+	format  LogFormat
+	output  zapcore.WriteSyncer
+	onFatal func()
+}
+
+// WithLogFormat sets the encoding of log entries, the console format is used by default.
+func WithLogFormat(format LogFormat) LoggerOption {
+	return func(o *loggerOptions) {
+		o.format = format
+	}
+}
+
+// WithLogOutput sets the destination of log entries, stdout is used by default.
+func WithLogOutput(output zapcore.WriteSyncer) LoggerOption {
+	return func(o *loggerOptions) {
+		o.output = output
+	}
+}
+
+// WithOnFatal sets the function which is called after a fatal entry has been written and before the process exits.
+// Deferred functions aren't run on the exit, so it's the place to flush and close the log output.
+func WithOnFatal(onFatal func()) LoggerOption {
+	return func(o *loggerOptions) {
+		o.onFatal = onFatal
+	}
+}
+
+// fatalHook calls onFatal and exits the process after a fatal entry has been written.
+type fatalHook struct {
+	onFatal func()
+	exit    func(code int)
+}
+
+func (h fatalHook) OnWrite(_ *zapcore.CheckedEntry, _ []zapcore.Field) {
+	h.onFatal()
+	h.exit(1)
+}
+
+func SetupLogger(level string, options ...LoggerOption) (*zap.Logger, *zap.SugaredLogger) {
+	lo := loggerOptions{format: LogFormatConsole, output: zapcore.Lock(os.Stdout)}
+	for _, opt := range options {
+		opt(&lo)
+	}
 	al := zap.NewAtomicLevel()
 	var opts []zap.Option
 	switch strings.ToUpper(level) {
@@ -28,9 +73,20 @@ func SetupLogger(level string) (*zap.Logger, *zap.SugaredLogger) {
 	default:
 		al.SetLevel(zap.InfoLevel)
 	}
-	ec := zap.NewDevelopmentEncoderConfig()
-	core := zapcore.NewCore(zapcore.NewConsoleEncoder(ec), zapcore.Lock(os.Stdout), al)
+	var enc zapcore.Encoder
+	switch lo.format {
+	case LogFormatJSON:
+		ec := zap.NewProductionEncoderConfig()
+		ec.EncodeTime = zapcore.ISO8601TimeEncoder
+		enc = zapcore.NewJSONEncoder(ec)
+	default:
+		enc = zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	}
+	core := zapcore.NewCore(enc, lo.output, al)
 	logger := zap.New(core)
+	if lo.onFatal != nil {
+		logger = logger.WithOptions(zap.WithFatalHook(fatalHook{onFatal: lo.onFatal, exit: os.Exit}))
+	}
 	zap.ReplaceGlobals(logger.WithOptions(opts...))
 	return logger, logger.Sugar()
 }
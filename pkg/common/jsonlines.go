@@ -0,0 +1,42 @@
+package common
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// TruncatePartialLine truncates the last line of the file if it doesn't end with a newline. Such line is left when
+// the process crashes while appending it, so it has to be removed before further appends. Returns the count of
+// truncated bytes.
+func TruncatePartialLine(f *os.File) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get file info")
+	}
+	size := info.Size()
+	buf := make([]byte, 4096)
+	end := size
+	for end > 0 {
+		n := int64(len(buf))
+		if n > end {
+			n = end
+		}
+		if _, err := f.ReadAt(buf[:n], end-n); err != nil {
+			return 0, errors.Wrap(err, "failed to read file")
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			end = end - n + int64(i) + 1
+			break
+		}
+		end -= n
+	}
+	if end == size {
+		return 0, nil
+	}
+	if err := f.Truncate(end); err != nil {
+		return 0, errors.Wrap(err, "failed to truncate file")
+	}
+	return size - end, nil
+}
@@ -0,0 +1,43 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncatePartialLine(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+	tests := []struct {
+		content   string
+		expected  string
+		truncated int64
+	}{
+		{content: "", expected: ""},
+		{content: "{\"a\":1}\n{\"a\":2}\n", expected: "{\"a\":1}\n{\"a\":2}\n"},
+		{content: "{\"a\":1}\n{\"a\":", expected: "{\"a\":1}\n", truncated: 5},
+		{content: "{\"a\":", expected: "", truncated: 5},
+		{content: "{\"a\":1}\n" + long, expected: "{\"a\":1}\n", truncated: int64(len(long))},
+		{content: long + "\n" + long, expected: long + "\n", truncated: int64(len(long))},
+	}
+	for i, tc := range tests {
+		path := filepath.Join(t.TempDir(), "lines.jsonl")
+		require.NoError(t, os.WriteFile(path, []byte(tc.content), 0644))
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_RDWR, 0644)
+		require.NoError(t, err)
+
+		truncated, err := TruncatePartialLine(f)
+		require.NoError(t, err, "failed test case #%d", i)
+		require.Equal(t, tc.truncated, truncated, "failed test case #%d", i)
+		_, err = f.WriteString("{\"a\":3}\n")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		actual, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected+"{\"a\":3}\n", string(actual), "failed test case #%d", i)
+	}
+}
@@ -0,0 +1,73 @@
+package common
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// LogFormat is the encoding of log entries.
+type LogFormat string
+
+const (
+	LogFormatConsole LogFormat = "console"
+	LogFormatJSON    LogFormat = "json"
+)
+
+func NewLogFormatFromString(format string) (LogFormat, error) {
+	switch f := LogFormat(strings.ToLower(format)); f {
+	case LogFormatConsole, LogFormatJSON:
+		return f, nil
+	default:
+		return "", errors.Errorf("invalid log format %q", format)
+	}
+}
+
+// LogOutput is the destination of log entries.
+type LogOutput string
+
+const (
+	LogOutputStdout LogOutput = "stdout"
+	LogOutputStderr LogOutput = "stderr"
+	LogOutputFile   LogOutput = "file"
+)
+
+// LogFile describes the log file and its size-based rotation. The file is rotated when it reaches MaxSizeMB megabytes,
+// zero value means 100 megabytes. Rotated files are kept until MaxBackups or MaxAgeDays limits are reached, zero
+// values mean no limit.
+type LogFile struct {
+	Path       string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+}
+
+// NewLogWriter opens the log output. The returned close function must be called when logging is no longer needed.
+func NewLogWriter(output string, file LogFile) (zapcore.WriteSyncer, func() error, error) {
+	noop := func() error { return nil }
+	switch LogOutput(strings.ToLower(output)) {
+	case LogOutputStdout:
+		return zapcore.Lock(os.Stdout), noop, nil
+	case LogOutputStderr:
+		return zapcore.Lock(os.Stderr), noop, nil
+	case LogOutputFile:
+		if file.Path == "" {
+			return nil, nil, errors.New("log file path is required for the file log output")
+		}
+		if file.MaxSizeMB < 0 || file.MaxBackups < 0 || file.MaxAgeDays < 0 {
+			return nil, nil, errors.New("log file rotation limits should be non negative")
+		}
+		l := &lumberjack.Logger{
+			Filename:   file.Path,
+			MaxSize:    file.MaxSizeMB,
+			MaxBackups: file.MaxBackups,
+			MaxAge:     file.MaxAgeDays,
+		}
+		return zapcore.AddSync(l), l.Close, nil
+	default:
+		return nil, nil, errors.Errorf("invalid log output %q", output)
+	}
+}
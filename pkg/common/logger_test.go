@@ -0,0 +1,89 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewLogFormatFromString(t *testing.T) {
+	format, err := NewLogFormatFromString("JSON")
+	require.NoError(t, err)
+	require.Equal(t, LogFormatJSON, format)
+	format, err = NewLogFormatFromString("console")
+	require.NoError(t, err)
+	require.Equal(t, LogFormatConsole, format)
+	_, err = NewLogFormatFromString("xml")
+	require.Error(t, err)
+}
+
+func TestSetupLogger_JSON(t *testing.T) {
+	defer zap.ReplaceGlobals(zap.L())
+
+	buf := &bytes.Buffer{}
+	_, s := SetupLogger("INFO", WithLogFormat(LogFormatJSON), WithLogOutput(zapcore.AddSync(buf)))
+	s.Debugw("skipped")
+	zap.S().Infow("monitor state has been successfully changed", "from", "active", "to", "frozen_degraded")
+
+	entry := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "info", entry["level"])
+	require.Equal(t, "monitor state has been successfully changed", entry["msg"])
+	require.Equal(t, "active", entry["from"])
+	require.Equal(t, "frozen_degraded", entry["to"])
+	require.Contains(t, entry, "ts")
+}
+
+func TestNewLogWriter(t *testing.T) {
+	for _, output := range []string{"stdout", "STDERR"} {
+		ws, closeOutput, err := NewLogWriter(output, LogFile{})
+		require.NoError(t, err)
+		require.NotNil(t, ws)
+		require.NoError(t, closeOutput())
+	}
+	_, _, err := NewLogWriter("syslog", LogFile{})
+	require.Error(t, err)
+	_, _, err = NewLogWriter("file", LogFile{})
+	require.Error(t, err)
+	_, _, err = NewLogWriter("file", LogFile{Path: "netmon.log", MaxBackups: -1})
+	require.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "netmon.log")
+	ws, closeOutput, err := NewLogWriter("file", LogFile{Path: path, MaxSizeMB: 1, MaxBackups: 2})
+	require.NoError(t, err)
+	_, err = ws.Write([]byte("entry\n"))
+	require.NoError(t, err)
+	require.NoError(t, ws.Sync())
+	require.NoError(t, closeOutput())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "entry\n", string(data))
+}
+
+func TestFatalHook(t *testing.T) {
+	buf := &bytes.Buffer{}
+	var calls []string
+	hook := fatalHook{
+		onFatal: func() { calls = append(calls, "onFatal") },
+		exit: func(code int) {
+			calls = append(calls, "exit")
+			require.Equal(t, 1, code)
+			panic("exit")
+		},
+	}
+	logger := zap.New(
+		zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(buf), zap.InfoLevel),
+		zap.WithFatalHook(hook),
+	)
+	require.PanicsWithValue(t, "exit", func() {
+		logger.Fatal("failed to init monitor")
+	})
+	require.Equal(t, []string{"onFatal", "exit"}, calls)
+	require.Contains(t, buf.String(), "failed to init monitor")
+}
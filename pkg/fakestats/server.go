@@ -0,0 +1,239 @@
+package fakestats
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// Node is a node statistics entry in the stats aggregator format.
+type Node struct {
+	NetByte         string `json:"netbyte"`
+	Height          int    `json:"height"`
+	StateHash       string `json:"statehash"`
+	StateHashHeight int    `json:"statehash_height"`
+	Version         string `json:"version"`
+}
+
+// Server is a fake nodes stats aggregator. Scenarios are scripted by calling its methods between scrapes.
+type Server struct {
+	srv *httptest.Server
+
+	mu            sync.Mutex
+	nodes         map[string]Node
+	delay         time.Duration
+	failureCode   int
+	failuresLeft  int // negative value means that server fails until Reset
+	oversizedBody int
+	rawBody       []byte
+	requests      int
+	lastHeader    http.Header
+}
+
+// NewServer starts the fake stats server. The server must be closed by the caller.
+func NewServer() *Server {
+	s := &Server{nodes: make(map[string]Node)}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// LastRequestHeader returns headers of the latest received request.
+func (s *Server) LastRequestHeader() http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastHeader.Clone()
+}
+
+// Requests returns count of received requests.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) SetNode(domain string, node Node) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes[domain] = node
+}
+
+// AddNodes adds working nodes with the same height and state hash.
+func (s *Server) AddNodes(netByte string, height int, stateHash string, domains ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, domain := range domains {
+		s.nodes[domain] = Node{
+			NetByte:         netByte,
+			Height:          height,
+			StateHash:       stateHash,
+			StateHashHeight: height,
+			Version:         "Waves v1.4.1",
+		}
+	}
+}
+
+func (s *Server) RemoveNodes(domains ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, domain := range domains {
+		delete(s.nodes, domain)
+	}
+}
+
+// Nodes returns a copy of current nodes.
+func (s *Server) Nodes() map[string]Node {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nodes := make(map[string]Node, len(s.nodes))
+	for domain, node := range s.nodes {
+		nodes[domain] = node
+	}
+	return nodes
+}
+
+func (s *Server) updateNodes(update func(node *Node), domains ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, domain := range domains {
+		node, ok := s.nodes[domain]
+		if !ok {
+			continue
+		}
+		update(&node)
+		s.nodes[domain] = node
+	}
+}
+
+// NodesDown makes nodes report the aggregator's "down" values.
+func (s *Server) NodesDown(domains ...string) {
+	s.updateNodes(func(node *Node) {
+		node.Height = -1
+		node.StateHash = ""
+		node.StateHashHeight = -1
+	}, domains...)
+}
+
+func (s *Server) SetHeight(height int, domains ...string) {
+	s.updateNodes(func(node *Node) {
+		node.Height = height
+		node.StateHashHeight = height
+	}, domains...)
+}
+
+// LagHeight moves nodes the given amount of blocks back.
+func (s *Server) LagHeight(lag int, domains ...string) {
+	s.updateNodes(func(node *Node) {
+		node.Height -= lag
+		node.StateHashHeight -= lag
+	}, domains...)
+}
+
+// Fork sets the state hash of nodes, which splits them from the nodes on the same height.
+func (s *Server) Fork(stateHash string, domains ...string) {
+	s.updateNodes(func(node *Node) {
+		node.StateHash = stateHash
+	}, domains...)
+}
+
+// SetResponseDelay delays every response by d.
+func (s *Server) SetResponseDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// FailWithStatus makes the next n responses fail with the given HTTP status code.
+// If n isn't positive, the server fails until Reset.
+func (s *Server) FailWithStatus(code int, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failureCode = code
+	if n <= 0 {
+		n = -1
+	}
+	s.failuresLeft = n
+}
+
+// SetOversizedBody pads valid responses with leading whitespaces up to the given size in bytes.
+func (s *Server) SetOversizedBody(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.oversizedBody = size
+}
+
+// SetRawBody makes the server respond with the given body instead of the nodes. Nil body disables it.
+func (s *Server) SetRawBody(body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rawBody = body
+}
+
+// Reset disables all failure scenarios. Nodes are kept.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = 0
+	s.failureCode = 0
+	s.failuresLeft = 0
+	s.oversizedBody = 0
+	s.rawBody = nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	s.lastHeader = r.Header.Clone()
+	delay := s.delay
+	failureCode := 0
+	if s.failuresLeft != 0 {
+		failureCode = s.failureCode
+		if s.failuresLeft > 0 {
+			s.failuresLeft--
+		}
+	}
+	body, err := s.unsafeBody()
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+	if failureCode != 0 {
+		http.Error(w, http.StatusText(failureCode), failureCode)
+		return
+	}
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func (s *Server) unsafeBody() ([]byte, error) {
+	if s.rawBody != nil {
+		return s.rawBody, nil
+	}
+	body, err := json.Marshal(s.nodes)
+	if err != nil {
+		return nil, err
+	}
+	if padding := s.oversizedBody - len(body); padding > 0 {
+		body = append(bytes.Repeat([]byte{' '}, padding), body...)
+	}
+	return body, nil
+}
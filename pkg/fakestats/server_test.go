@@ -0,0 +1,89 @@
+package fakestats
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, url string) (int, []byte) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, resp.Body.Close())
+	}()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, body
+}
+
+func getNodes(t *testing.T, url string) map[string]Node {
+	code, body := get(t, url)
+	require.Equal(t, http.StatusOK, code)
+	var nodes map[string]Node
+	require.NoError(t, json.Unmarshal(body, &nodes))
+	return nodes
+}
+
+func TestServer_Nodes(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.AddNodes("W", 100, "aa", "n1", "n2", "n3", "n4")
+	srv.NodesDown("n1")
+	srv.LagHeight(10, "n2")
+	srv.Fork("bb", "n3")
+	srv.SetNode("n5", Node{NetByte: "T", Height: 5})
+	srv.RemoveNodes("n4")
+
+	expected := map[string]Node{
+		"n1": {NetByte: "W", Height: -1, StateHashHeight: -1, Version: "Waves v1.4.1"},
+		"n2": {NetByte: "W", Height: 90, StateHash: "aa", StateHashHeight: 90, Version: "Waves v1.4.1"},
+		"n3": {NetByte: "W", Height: 100, StateHash: "bb", StateHashHeight: 100, Version: "Waves v1.4.1"},
+		"n5": {NetByte: "T", Height: 5},
+	}
+	require.Equal(t, expected, srv.Nodes())
+	require.Equal(t, expected, getNodes(t, srv.URL()))
+	require.Equal(t, 1, srv.Requests())
+}
+
+func TestServer_Failures(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddNodes("W", 100, "aa", "n1")
+
+	srv.FailWithStatus(http.StatusBadGateway, 2)
+	for i := 0; i < 2; i++ {
+		code, _ := get(t, srv.URL())
+		require.Equal(t, http.StatusBadGateway, code)
+	}
+	require.Len(t, getNodes(t, srv.URL()), 1)
+
+	srv.FailWithStatus(http.StatusInternalServerError, 0)
+	for i := 0; i < 3; i++ {
+		code, _ := get(t, srv.URL())
+		require.Equal(t, http.StatusInternalServerError, code)
+	}
+	srv.Reset()
+
+	srv.SetOversizedBody(1024)
+	code, body := get(t, srv.URL())
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, body, 1024)
+	srv.Reset()
+
+	srv.SetRawBody([]byte("{corruptedData"))
+	code, body = get(t, srv.URL())
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "{corruptedData", string(body))
+	srv.Reset()
+
+	srv.SetResponseDelay(50 * time.Millisecond)
+	start := time.Now()
+	require.Len(t, getNodes(t, srv.URL()), 1)
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
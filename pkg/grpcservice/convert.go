@@ -0,0 +1,144 @@
+package grpcservice
+
+import (
+	"time"
+
+	"github.com/nickeskov/netmon/pkg/grpcservice/netmonpb"
+	"github.com/nickeskov/netmon/pkg/monitor"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toTimestamp converts the time to the timestamp, zero time is converted to the unset timestamp.
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func toSeverity(severity monitor.Severity) netmonpb.Severity {
+	switch severity {
+	case monitor.SeverityOK:
+		return netmonpb.Severity_SEVERITY_OK
+	case monitor.SeverityWarning:
+		return netmonpb.Severity_SEVERITY_WARNING
+	case monitor.SeverityDegraded:
+		return netmonpb.Severity_SEVERITY_DEGRADED
+	case monitor.SeverityCritical:
+		return netmonpb.Severity_SEVERITY_CRITICAL
+	default:
+		return netmonpb.Severity_SEVERITY_UNSPECIFIED
+	}
+}
+
+func toState(state monitor.NetworkMonitoringState) netmonpb.State {
+	switch state {
+	case monitor.StateActive:
+		return netmonpb.State_STATE_ACTIVE
+	case monitor.StateFrozenNetworkOperatesStable:
+		return netmonpb.State_STATE_FROZEN_OPERATES_STABLE
+	case monitor.StateFrozenNetworkDegraded:
+		return netmonpb.State_STATE_FROZEN_DEGRADED
+	default:
+		return netmonpb.State_STATE_UNSPECIFIED
+	}
+}
+
+func fromState(state netmonpb.State) (monitor.NetworkMonitoringState, error) {
+	switch state {
+	case netmonpb.State_STATE_ACTIVE:
+		return monitor.StateActive, nil
+	case netmonpb.State_STATE_FROZEN_OPERATES_STABLE:
+		return monitor.StateFrozenNetworkOperatesStable, nil
+	case netmonpb.State_STATE_FROZEN_DEGRADED:
+		return monitor.StateFrozenNetworkDegraded, nil
+	default:
+		return 0, errors.Errorf("invalid monitor state %q", state)
+	}
+}
+
+func toNodeClass(class monitor.NodeClass) netmonpb.NodeClass {
+	switch class {
+	case monitor.NodeClassValid:
+		return netmonpb.NodeClass_NODE_CLASS_VALID
+	case monitor.NodeClassDown:
+		return netmonpb.NodeClass_NODE_CLASS_DOWN
+	case monitor.NodeClassSyncing:
+		return netmonpb.NodeClass_NODE_CLASS_SYNCING
+	case monitor.NodeClassMalformed:
+		return netmonpb.NodeClass_NODE_CLASS_MALFORMED
+	default:
+		return netmonpb.NodeClass_NODE_CLASS_UNSPECIFIED
+	}
+}
+
+func toNetworkStatus(info monitor.NetworkStatusInfo) *netmonpb.NetworkStatus {
+	reasons := make([]*netmonpb.SeverityReason, 0, len(info.Reasons))
+	for _, reason := range info.Reasons {
+		reasons = append(reasons, &netmonpb.SeverityReason{
+			Reason:   reason.Reason,
+			Severity: toSeverity(reason.Severity),
+			Since:    toTimestamp(reason.Since),
+		})
+	}
+	return &netmonpb.NetworkStatus{
+		Updated:  toTimestamp(info.Updated),
+		Network:  string(info.Network),
+		Status:   info.Status,
+		Height:   int64(info.Height),
+		Severity: toSeverity(info.Severity),
+		Reasons:  reasons,
+	}
+}
+
+func toListNodesResponse(info monitor.NetworkNodesInfo) *netmonpb.ListNodesResponse {
+	nodes := make([]*netmonpb.Node, 0, len(info.Nodes))
+	for _, node := range info.Nodes {
+		nodes = append(nodes, &netmonpb.Node{
+			Domain:          node.Domain,
+			Height:          int64(node.Height),
+			Statehash:       node.StateHash,
+			StatehashHeight: int64(node.StateHashHeight),
+			Version:         node.Version,
+			Class:           toNodeClass(node.Class),
+			MalformedReason: node.MalformedReason,
+			Lag:             int64(node.Lag),
+			Lagging:         node.Lagging,
+		})
+	}
+	return &netmonpb.ListNodesResponse{
+		Updated: toTimestamp(info.Updated),
+		Network: string(info.Network),
+		Nodes:   nodes,
+	}
+}
+
+func toGetHistoryResponse(info monitor.NetworkHistoryInfo) *netmonpb.GetHistoryResponse {
+	points := make([]*netmonpb.HistoryPoint, 0, len(info.Points))
+	for _, point := range info.Points {
+		points = append(points, &netmonpb.HistoryPoint{
+			Timestamp:    toTimestamp(point.Timestamp),
+			Height:       int64(point.Height),
+			Nodes:        int64(point.Nodes),
+			WorkingNodes: int64(point.WorkingNodes),
+			Alerted:      point.Alerted,
+			Criteria:     point.Criteria,
+		})
+	}
+	return &netmonpb.GetHistoryResponse{
+		Updated: toTimestamp(info.Updated),
+		Network: string(info.Network),
+		Points:  points,
+	}
+}
+
+func toGetStateResponse(state monitor.NetworkMonitoringState, loop monitor.LoopInfo) *netmonpb.GetStateResponse {
+	return &netmonpb.GetStateResponse{
+		State:                toState(state),
+		LoopStarted:          toTimestamp(loop.Started),
+		LastSuccessfulScrape: toTimestamp(loop.LastSuccessfulScrape),
+		PollInterval:         durationpb.New(loop.PollInterval),
+	}
+}
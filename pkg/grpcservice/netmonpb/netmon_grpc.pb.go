@@ -0,0 +1,325 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: pkg/grpcservice/netmonpb/netmon.proto
+
+package netmonpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	NetworkMonitor_GetHealth_FullMethodName   = "/netmon.v1.NetworkMonitor/GetHealth"
+	NetworkMonitor_ListNodes_FullMethodName   = "/netmon.v1.NetworkMonitor/ListNodes"
+	NetworkMonitor_GetHistory_FullMethodName  = "/netmon.v1.NetworkMonitor/GetHistory"
+	NetworkMonitor_GetState_FullMethodName    = "/netmon.v1.NetworkMonitor/GetState"
+	NetworkMonitor_SetState_FullMethodName    = "/netmon.v1.NetworkMonitor/SetState"
+	NetworkMonitor_WatchStatus_FullMethodName = "/netmon.v1.NetworkMonitor/WatchStatus"
+)
+
+// NetworkMonitorClient is the client API for NetworkMonitor service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// NetworkMonitor is the gRPC counterpart of the HTTP API. SetState requires the auth token in the metadata key which
+// is the lowercase HTTP auth header, e.g. 'x-waves-monitor-auth'.
+type NetworkMonitorClient interface {
+	GetHealth(ctx context.Context, in *GetHealthRequest, opts ...grpc.CallOption) (*NetworkStatus, error)
+	ListNodes(ctx context.Context, in *ListNodesRequest, opts ...grpc.CallOption) (*ListNodesResponse, error)
+	GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error)
+	GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*GetStateResponse, error)
+	SetState(ctx context.Context, in *SetStateRequest, opts ...grpc.CallOption) (*SetStateResponse, error)
+	// WatchStatus sends the current network status and then the status after each check of the nodes.
+	// Slow watchers receive only the latest status.
+	WatchStatus(ctx context.Context, in *WatchStatusRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[NetworkStatus], error)
+}
+
+type networkMonitorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNetworkMonitorClient(cc grpc.ClientConnInterface) NetworkMonitorClient {
+	return &networkMonitorClient{cc}
+}
+
+func (c *networkMonitorClient) GetHealth(ctx context.Context, in *GetHealthRequest, opts ...grpc.CallOption) (*NetworkStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NetworkStatus)
+	err := c.cc.Invoke(ctx, NetworkMonitor_GetHealth_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *networkMonitorClient) ListNodes(ctx context.Context, in *ListNodesRequest, opts ...grpc.CallOption) (*ListNodesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListNodesResponse)
+	err := c.cc.Invoke(ctx, NetworkMonitor_ListNodes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *networkMonitorClient) GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetHistoryResponse)
+	err := c.cc.Invoke(ctx, NetworkMonitor_GetHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *networkMonitorClient) GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*GetStateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStateResponse)
+	err := c.cc.Invoke(ctx, NetworkMonitor_GetState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *networkMonitorClient) SetState(ctx context.Context, in *SetStateRequest, opts ...grpc.CallOption) (*SetStateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetStateResponse)
+	err := c.cc.Invoke(ctx, NetworkMonitor_SetState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *networkMonitorClient) WatchStatus(ctx context.Context, in *WatchStatusRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[NetworkStatus], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &NetworkMonitor_ServiceDesc.Streams[0], NetworkMonitor_WatchStatus_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchStatusRequest, NetworkStatus]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NetworkMonitor_WatchStatusClient = grpc.ServerStreamingClient[NetworkStatus]
+
+// NetworkMonitorServer is the server API for NetworkMonitor service.
+// All implementations must embed UnimplementedNetworkMonitorServer
+// for forward compatibility.
+//
+// NetworkMonitor is the gRPC counterpart of the HTTP API. SetState requires the auth token in the metadata key which
+// is the lowercase HTTP auth header, e.g. 'x-waves-monitor-auth'.
+type NetworkMonitorServer interface {
+	GetHealth(context.Context, *GetHealthRequest) (*NetworkStatus, error)
+	ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error)
+	GetHistory(context.Context, *GetHistoryRequest) (*GetHistoryResponse, error)
+	GetState(context.Context, *GetStateRequest) (*GetStateResponse, error)
+	SetState(context.Context, *SetStateRequest) (*SetStateResponse, error)
+	// WatchStatus sends the current network status and then the status after each check of the nodes.
+	// Slow watchers receive only the latest status.
+	WatchStatus(*WatchStatusRequest, grpc.ServerStreamingServer[NetworkStatus]) error
+	mustEmbedUnimplementedNetworkMonitorServer()
+}
+
+// UnimplementedNetworkMonitorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedNetworkMonitorServer struct{}
+
+func (UnimplementedNetworkMonitorServer) GetHealth(context.Context, *GetHealthRequest) (*NetworkStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHealth not implemented")
+}
+func (UnimplementedNetworkMonitorServer) ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNodes not implemented")
+}
+func (UnimplementedNetworkMonitorServer) GetHistory(context.Context, *GetHistoryRequest) (*GetHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHistory not implemented")
+}
+func (UnimplementedNetworkMonitorServer) GetState(context.Context, *GetStateRequest) (*GetStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetState not implemented")
+}
+func (UnimplementedNetworkMonitorServer) SetState(context.Context, *SetStateRequest) (*SetStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetState not implemented")
+}
+func (UnimplementedNetworkMonitorServer) WatchStatus(*WatchStatusRequest, grpc.ServerStreamingServer[NetworkStatus]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchStatus not implemented")
+}
+func (UnimplementedNetworkMonitorServer) mustEmbedUnimplementedNetworkMonitorServer() {}
+func (UnimplementedNetworkMonitorServer) testEmbeddedByValue()                        {}
+
+// UnsafeNetworkMonitorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NetworkMonitorServer will
+// result in compilation errors.
+type UnsafeNetworkMonitorServer interface {
+	mustEmbedUnimplementedNetworkMonitorServer()
+}
+
+func RegisterNetworkMonitorServer(s grpc.ServiceRegistrar, srv NetworkMonitorServer) {
+	// If the following call pancis, it indicates UnimplementedNetworkMonitorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&NetworkMonitor_ServiceDesc, srv)
+}
+
+func _NetworkMonitor_GetHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkMonitorServer).GetHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NetworkMonitor_GetHealth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkMonitorServer).GetHealth(ctx, req.(*GetHealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NetworkMonitor_ListNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkMonitorServer).ListNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NetworkMonitor_ListNodes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkMonitorServer).ListNodes(ctx, req.(*ListNodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NetworkMonitor_GetHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkMonitorServer).GetHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NetworkMonitor_GetHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkMonitorServer).GetHistory(ctx, req.(*GetHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NetworkMonitor_GetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkMonitorServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NetworkMonitor_GetState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkMonitorServer).GetState(ctx, req.(*GetStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NetworkMonitor_SetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkMonitorServer).SetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NetworkMonitor_SetState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkMonitorServer).SetState(ctx, req.(*SetStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NetworkMonitor_WatchStatus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchStatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NetworkMonitorServer).WatchStatus(m, &grpc.GenericServerStream[WatchStatusRequest, NetworkStatus]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NetworkMonitor_WatchStatusServer = grpc.ServerStreamingServer[NetworkStatus]
+
+// NetworkMonitor_ServiceDesc is the grpc.ServiceDesc for NetworkMonitor service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var NetworkMonitor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "netmon.v1.NetworkMonitor",
+	HandlerType: (*NetworkMonitorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetHealth",
+			Handler:    _NetworkMonitor_GetHealth_Handler,
+		},
+		{
+			MethodName: "ListNodes",
+			Handler:    _NetworkMonitor_ListNodes_Handler,
+		},
+		{
+			MethodName: "GetHistory",
+			Handler:    _NetworkMonitor_GetHistory_Handler,
+		},
+		{
+			MethodName: "GetState",
+			Handler:    _NetworkMonitor_GetState_Handler,
+		},
+		{
+			MethodName: "SetState",
+			Handler:    _NetworkMonitor_SetState_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchStatus",
+			Handler:       _NetworkMonitor_WatchStatus_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/grpcservice/netmonpb/netmon.proto",
+}
@@ -0,0 +1,37 @@
+package grpcservice
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// NewServer returns the gRPC server with the registered service and the server reflection. Contexts of streams are
+// cancelled when the given context is done, so watchers don't block the graceful stop of the server.
+func NewServer(ctx context.Context, svc *NetworkMonitoringService, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainStreamInterceptor(cancelStreamsOnDone(ctx)))
+	srv := grpc.NewServer(opts...)
+	svc.Register(srv)
+	reflection.Register(srv)
+	return srv
+}
+
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func cancelStreamsOnDone(ctx context.Context) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		streamCtx, cancel := context.WithCancel(ss.Context())
+		defer cancel()
+		stop := context.AfterFunc(ctx, cancel)
+		defer stop()
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: streamCtx})
+	}
+}
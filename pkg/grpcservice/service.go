@@ -0,0 +1,120 @@
+package grpcservice
+
+import (
+	"context"
+	"strings"
+
+	"github.com/nickeskov/netmon/pkg/grpcservice/netmonpb"
+	"github.com/nickeskov/netmon/pkg/monitor"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// NetworkMonitoringService serves the gRPC API of the monitor, it's the counterpart of the HTTP service.
+// Private methods are authenticated with the same token as private HTTP routes, which is passed in the metadata key
+// equal to the lowercase HTTP auth header.
+type NetworkMonitoringService struct {
+	netmonpb.UnimplementedNetworkMonitorServer
+
+	monitor   monitor.Monitor
+	authKey   string
+	authToken string
+}
+
+func NewNetworkMonitoringService(monitor monitor.Monitor, authHeader, authToken string) *NetworkMonitoringService {
+	return &NetworkMonitoringService{
+		monitor:   monitor,
+		authKey:   strings.ToLower(authHeader),
+		authToken: authToken,
+	}
+}
+
+// Register registers the service on the gRPC server.
+func (s *NetworkMonitoringService) Register(srv *grpc.Server) {
+	netmonpb.RegisterNetworkMonitorServer(srv, s)
+}
+
+func (s *NetworkMonitoringService) authorize(ctx context.Context) error {
+	var token string
+	md, _ := metadata.FromIncomingContext(ctx)
+	if tokens := md.Get(s.authKey); len(tokens) != 0 {
+		token = tokens[0]
+	}
+	if token != s.authToken {
+		method, _ := grpc.Method(ctx)
+		zap.S().Warnw("auth token check has failed", "api", "grpc", "method", method, "peer", peerAddr(ctx))
+		return status.Error(codes.PermissionDenied, "invalid auth token")
+	}
+	return nil
+}
+
+// peerAddr returns the address of the client or an empty string if it's unknown.
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+func (s *NetworkMonitoringService) GetHealth(context.Context, *netmonpb.GetHealthRequest) (*netmonpb.NetworkStatus, error) {
+	return toNetworkStatus(s.monitor.NetworkStatusInfo()), nil
+}
+
+func (s *NetworkMonitoringService) ListNodes(context.Context, *netmonpb.ListNodesRequest) (*netmonpb.ListNodesResponse, error) {
+	return toListNodesResponse(s.monitor.NetworkNodesInfo()), nil
+}
+
+func (s *NetworkMonitoringService) GetHistory(context.Context, *netmonpb.GetHistoryRequest) (*netmonpb.GetHistoryResponse, error) {
+	return toGetHistoryResponse(s.monitor.NetworkHistoryInfo()), nil
+}
+
+func (s *NetworkMonitoringService) GetState(context.Context, *netmonpb.GetStateRequest) (*netmonpb.GetStateResponse, error) {
+	return toGetStateResponse(s.monitor.State(), s.monitor.LoopInfo()), nil
+}
+
+// SetState requires the auth token.
+func (s *NetworkMonitoringService) SetState(ctx context.Context, req *netmonpb.SetStateRequest) (*netmonpb.SetStateResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	newMonState, err := fromState(req.GetState())
+	if err != nil {
+		zap.S().Warnw("invalid set monitor state request", "api", "grpc", "peer", peerAddr(ctx), zap.Error(err))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	prevMonState := s.monitor.ChangeState(newMonState)
+	fields := []any{"api", "grpc", "peer", peerAddr(ctx), "from", prevMonState.String(), "to", newMonState.String()}
+	if prevMonState != newMonState {
+		zap.S().Infow("monitor state has been successfully changed", fields...)
+	} else {
+		zap.S().Infow("monitor state hasn't been changed", fields...)
+	}
+	return &netmonpb.SetStateResponse{Previous: toState(prevMonState)}, nil
+}
+
+// WatchStatus sends the current network status and then the status after each successful check of the nodes.
+func (s *NetworkMonitoringService) WatchStatus(_ *netmonpb.WatchStatusRequest, stream netmonpb.NetworkMonitor_WatchStatusServer) error {
+	updates, cancel := s.monitor.WatchStatus()
+	defer cancel()
+
+	if err := stream.Send(toNetworkStatus(s.monitor.NetworkStatusInfo())); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case statusInfo, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(toNetworkStatus(statusInfo)); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package grpcservice
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nickeskov/netmon/pkg/grpcservice/netmonpb"
+	"github.com/nickeskov/netmon/pkg/monitor"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// newTestClient serves the service on the in-memory listener until the context is done.
+func newTestClient(ctx context.Context, t *testing.T, mon monitor.Monitor) netmonpb.NetworkMonitorClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(ctx, NewNetworkMonitoringService(mon, "X-Auth", "token"))
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return netmonpb.NewNetworkMonitorClient(conn)
+}
+
+func TestNetworkMonitoringService_Getters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	mockMonitor := monitor.NewMockMonitor(ctrl)
+	mockMonitor.EXPECT().NetworkStatusInfo().Times(1).Return(monitor.NetworkStatusInfo{
+		Updated:  now,
+		Network:  monitor.MainNetSchemeChar,
+		Height:   10,
+		Severity: monitor.SeverityCritical,
+		Reasons: []monitor.SeverityReason{
+			{Reason: monitor.CriterionStateHash, Severity: monitor.SeverityCritical, Since: now.Add(-time.Minute)},
+		},
+	})
+	mockMonitor.EXPECT().NetworkNodesInfo().Times(1).Return(monitor.NetworkNodesInfo{
+		Updated: now,
+		Network: monitor.MainNetSchemeChar,
+		Nodes: []monitor.NodeInfo{
+			{Domain: "a", Height: 10, StateHash: "aa", StateHashHeight: 9, Class: monitor.NodeClassValid},
+			{Domain: "b", Height: 7, Class: monitor.NodeClassValid, Lag: 3, Lagging: true},
+			{Domain: "c", Height: -1, Class: monitor.NodeClassDown},
+		},
+	})
+	mockMonitor.EXPECT().NetworkHistoryInfo().Times(1).Return(monitor.NetworkHistoryInfo{
+		Updated: now,
+		Network: monitor.MainNetSchemeChar,
+		Points: []monitor.HistoryPoint{
+			{Timestamp: now, Height: 10, Nodes: 3, WorkingNodes: 2, Alerted: true, Criteria: []string{monitor.CriterionNodesDown}},
+		},
+	})
+	mockMonitor.EXPECT().State().Times(1).Return(monitor.StateFrozenNetworkDegraded)
+	mockMonitor.EXPECT().LoopInfo().Times(1).Return(monitor.LoopInfo{Started: now, PollInterval: time.Minute})
+	client := newTestClient(ctx, t, mockMonitor)
+
+	health, err := client.GetHealth(ctx, &netmonpb.GetHealthRequest{})
+	require.NoError(t, err)
+	require.Equal(t, netmonpb.Severity_SEVERITY_CRITICAL, health.GetSeverity())
+	require.Equal(t, int64(10), health.GetHeight())
+	require.Equal(t, "W", health.GetNetwork())
+	require.Equal(t, now, health.GetUpdated().AsTime())
+	require.Len(t, health.GetReasons(), 1)
+	require.Equal(t, monitor.CriterionStateHash, health.GetReasons()[0].GetReason())
+
+	nodes, err := client.ListNodes(ctx, &netmonpb.ListNodesRequest{})
+	require.NoError(t, err)
+	require.Len(t, nodes.GetNodes(), 3)
+	require.Equal(t, "aa", nodes.GetNodes()[0].GetStatehash())
+	require.Equal(t, int64(9), nodes.GetNodes()[0].GetStatehashHeight())
+	require.True(t, nodes.GetNodes()[1].GetLagging())
+	require.Equal(t, netmonpb.NodeClass_NODE_CLASS_DOWN, nodes.GetNodes()[2].GetClass())
+
+	history, err := client.GetHistory(ctx, &netmonpb.GetHistoryRequest{})
+	require.NoError(t, err)
+	require.Len(t, history.GetPoints(), 1)
+	require.Equal(t, int64(2), history.GetPoints()[0].GetWorkingNodes())
+	require.Equal(t, []string{monitor.CriterionNodesDown}, history.GetPoints()[0].GetCriteria())
+
+	state, err := client.GetState(ctx, &netmonpb.GetStateRequest{})
+	require.NoError(t, err)
+	require.Equal(t, netmonpb.State_STATE_FROZEN_DEGRADED, state.GetState())
+	require.Equal(t, timestamppb.New(now).AsTime(), state.GetLoopStarted().AsTime())
+	require.Nil(t, state.GetLastSuccessfulScrape())
+	require.Equal(t, durationpb.New(time.Minute).AsDuration(), state.GetPollInterval().AsDuration())
+}
+
+func TestNetworkMonitoringService_SetState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockMonitor := monitor.NewMockMonitor(ctrl)
+	mockMonitor.EXPECT().ChangeState(monitor.StateFrozenNetworkOperatesStable).Times(1).Return(monitor.StateActive)
+	client := newTestClient(ctx, t, mockMonitor)
+
+	req := &netmonpb.SetStateRequest{State: netmonpb.State_STATE_FROZEN_OPERATES_STABLE}
+	_, err := client.SetState(ctx, req)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.SetState(metadata.AppendToOutgoingContext(ctx, "x-auth", "invalid"), req)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	authCtx := metadata.AppendToOutgoingContext(ctx, "x-auth", "token")
+	_, err = client.SetState(authCtx, &netmonpb.SetStateRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	resp, err := client.SetState(authCtx, req)
+	require.NoError(t, err)
+	require.Equal(t, netmonpb.State_STATE_ACTIVE, resp.GetPrevious())
+}
+
+func TestNetworkMonitoringService_WatchStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	serverCtx, stopServer := context.WithCancel(context.Background())
+	defer stopServer()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updates := make(chan monitor.NetworkStatusInfo, 1)
+	unsubscribed := make(chan struct{})
+	mockMonitor := monitor.NewMockMonitor(ctrl)
+	mockMonitor.EXPECT().WatchStatus().Times(1).Return(updates, func() { close(unsubscribed) })
+	mockMonitor.EXPECT().NetworkStatusInfo().Times(1).Return(monitor.NetworkStatusInfo{Height: 10})
+	client := newTestClient(serverCtx, t, mockMonitor)
+
+	stream, err := client.WatchStatus(ctx, &netmonpb.WatchStatusRequest{})
+	require.NoError(t, err)
+	current, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, int64(10), current.GetHeight())
+
+	updates <- monitor.NetworkStatusInfo{Height: 11, Severity: monitor.SeverityDegraded}
+	update, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, int64(11), update.GetHeight())
+	require.Equal(t, netmonpb.Severity_SEVERITY_DEGRADED, update.GetSeverity())
+
+	// stream is closed when the server is stopping
+	stopServer()
+	_, err = stream.Recv()
+	require.Equal(t, codes.Canceled, status.Code(err))
+	<-unsubscribed
+}
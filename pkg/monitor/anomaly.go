@@ -0,0 +1,148 @@
+package monitor
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	IndicatorDownNodesPart   = "down_nodes_part"
+	IndicatorHeightSpread    = "height_spread"
+	IndicatorStateHashGroups = "statehash_groups"
+)
+
+// Standard deviation floors of the indicators. They prevent alerts on tiny deviations from perfectly stable baselines,
+// e.g. when none of nodes has been down during the whole learning period.
+const (
+	minDownNodesPartStdDev   = 0.02
+	minHeightSpreadStdDev    = 1
+	minStateHashGroupsStdDev = 0.25
+)
+
+// AnomalyCriterion is optional, it's disabled if Sigma is zero.
+// It keeps exponentially weighted moving average and variance of network indicators and is generated if any indicator
+// grows above its mean by more than Sigma standard deviations. Alpha is the smoothing factor of the moving averages,
+// the criterion isn't generated until baselines have learned from WarmUp samples.
+type AnomalyCriterion struct {
+	Sigma  float64 `json:"sigma"`
+	Alpha  float64 `json:"alpha"`
+	WarmUp int     `json:"warm_up"`
+}
+
+func (c *AnomalyCriterion) Enabled() bool {
+	return c.Sigma != 0
+}
+
+func (c *AnomalyCriterion) Validate() error {
+	if c.Sigma < 0 {
+		return errors.Errorf("AnomalyCriterion.Sigma value should be non negative")
+	}
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Alpha <= 0 || c.Alpha >= 1 {
+		return errors.Errorf("AnomalyCriterion.Alpha value should be 0.0 < n < 1.0")
+	}
+	if c.WarmUp < 0 {
+		return errors.Errorf("AnomalyCriterion.WarmUp value should be non negative")
+	}
+	return nil
+}
+
+// IndicatorBaseline is the learned baseline of the network indicator and its latest value.
+type IndicatorBaseline struct {
+	Name      string  `json:"name"`
+	Value     float64 `json:"value"`
+	Mean      float64 `json:"mean"`
+	StdDev    float64 `json:"stddev"`
+	Deviation float64 `json:"deviation"` // deviation of the latest value from the mean in standard deviations
+	Anomalous bool    `json:"anomalous"`
+}
+
+type NetworkBaselinesInfo struct {
+	Updated    time.Time           `json:"updated,omitempty"`
+	Network    NetworkSchemeChar   `json:"network"`
+	Enabled    bool                `json:"enabled"`
+	Samples    int                 `json:"samples"`
+	Indicators []IndicatorBaseline `json:"indicators"`
+}
+
+type ewmaBaseline struct {
+	name      string
+	minStdDev float64
+	mean      float64
+	variance  float64
+	last      IndicatorBaseline
+}
+
+// observe checks the value against the baseline and then updates the baseline with it.
+func (b *ewmaBaseline) observe(value float64, samples int, criterion AnomalyCriterion) bool {
+	if samples == 0 {
+		b.mean = value
+	}
+	stdDev := math.Sqrt(b.variance)
+	deviation := (value - b.mean) / math.Max(stdDev, b.minStdDev)
+	anomalous := samples >= criterion.WarmUp && deviation > criterion.Sigma
+	b.last = IndicatorBaseline{
+		Name:      b.name,
+		Value:     value,
+		Mean:      b.mean,
+		StdDev:    stdDev,
+		Deviation: deviation,
+		Anomalous: anomalous,
+	}
+	diff := value - b.mean
+	incr := criterion.Alpha * diff
+	b.mean += incr
+	b.variance = (1 - criterion.Alpha) * (b.variance + diff*incr)
+	return anomalous
+}
+
+type anomalyDetector struct {
+	criterion AnomalyCriterion
+	samples   int
+	updated   time.Time
+	baselines []*ewmaBaseline
+}
+
+func newAnomalyDetector(criterion AnomalyCriterion) anomalyDetector {
+	return anomalyDetector{
+		criterion: criterion,
+		baselines: []*ewmaBaseline{
+			{name: IndicatorDownNodesPart, minStdDev: minDownNodesPartStdDev},
+			{name: IndicatorHeightSpread, minStdDev: minHeightSpreadStdDev},
+			{name: IndicatorStateHashGroups, minStdDev: minStateHashGroupsStdDev},
+		},
+	}
+}
+
+// observe updates baselines with indicators of the calculator and reports whether any indicator is anomalous.
+// Indicators values must be in the same order as baselines.
+func (d *anomalyDetector) observe(now time.Time, calc *netstatCalculator) bool {
+	if !d.criterion.Enabled() {
+		return false
+	}
+	values := []float64{calc.DownNodesPart(), float64(calc.HeightSpread()), float64(calc.MaxStateHashGroups())}
+	anomalous := false
+	for i, baseline := range d.baselines {
+		if baseline.observe(values[i], d.samples, d.criterion) {
+			anomalous = true
+		}
+	}
+	d.samples++
+	d.updated = now
+	return anomalous
+}
+
+func (d *anomalyDetector) indicators() []IndicatorBaseline {
+	indicators := make([]IndicatorBaseline, 0, len(d.baselines))
+	if d.samples == 0 {
+		return indicators
+	}
+	for _, baseline := range d.baselines {
+		indicators = append(indicators, baseline.last)
+	}
+	return indicators
+}
@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEWMABaseline_Observe(t *testing.T) {
+	criterion := AnomalyCriterion{Sigma: 3, Alpha: 0.5, WarmUp: 2}
+	b := ewmaBaseline{name: "test", minStdDev: 1}
+
+	require.False(t, b.observe(10, 0, criterion))
+	require.Equal(t, IndicatorBaseline{Name: "test", Value: 10, Mean: 10}, b.last)
+	require.False(t, b.observe(100, 1, criterion)) // warming up
+	require.Equal(t, 55.0, b.mean)
+	require.Equal(t, 2025.0, b.variance)
+
+	require.False(t, b.observe(60, 2, criterion))
+	require.False(t, b.last.Anomalous)
+	require.True(t, b.observe(500, 3, criterion))
+	require.True(t, b.last.Anomalous)
+	require.Greater(t, b.last.Deviation, 3.0)
+	require.False(t, b.observe(-500, 4, criterion)) // decrease isn't an anomaly
+}
+
+func TestAnomalyDetector_Observe(t *testing.T) {
+	up := nodeWithStats{nodeStats: nodeStats{Height: 100, StateHash: "aa"}}
+	down := nodeWithStats{nodeStats: nodeStats{Height: -1}}
+	forked := nodeWithStats{nodeStats: nodeStats{Height: 100, StateHash: "bb"}}
+	observe := func(d *anomalyDetector, nodes ...nodeWithStats) bool {
+		calc, err := newNetstatCalculator(NetworkErrorCriteria{}, nodes)
+		require.NoError(t, err)
+		return d.observe(time.Now(), &calc)
+	}
+
+	disabled := newAnomalyDetector(AnomalyCriterion{})
+	require.False(t, observe(&disabled, down, down))
+	require.Equal(t, 0, disabled.samples)
+	require.Empty(t, disabled.indicators())
+
+	d := newAnomalyDetector(AnomalyCriterion{Sigma: 3, Alpha: 0.1, WarmUp: 5})
+	for i := 0; i < 10; i++ {
+		require.False(t, observe(&d, up, up, up, up, up, up, up, up, up, down), "sample %d", i)
+	}
+	require.True(t, observe(&d, up, up, up, up, up, down, down, down, down, down))
+	require.True(t, observe(&d, up, up, up, up, up, up, up, up, forked, forked))
+
+	indicators := d.indicators()
+	require.Len(t, indicators, 3)
+	require.Equal(t, IndicatorStateHashGroups, indicators[2].Name)
+	require.Equal(t, 2.0, indicators[2].Value)
+	require.True(t, indicators[2].Anomalous)
+	require.Equal(t, 12, d.samples)
+}
+
+func TestAnomalyCriterion_Validate(t *testing.T) {
+	require.NoError(t, (&AnomalyCriterion{}).Validate())
+	require.NoError(t, (&AnomalyCriterion{Sigma: 3, Alpha: 0.1, WarmUp: 30}).Validate())
+	require.Error(t, (&AnomalyCriterion{Sigma: -1}).Validate())
+	require.Error(t, (&AnomalyCriterion{Sigma: 3, Alpha: 0}).Validate())
+	require.Error(t, (&AnomalyCriterion{Sigma: 3, Alpha: 1}).Validate())
+	require.Error(t, (&AnomalyCriterion{Sigma: 3, Alpha: 0.1, WarmUp: -1}).Validate())
+}
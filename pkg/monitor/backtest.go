@@ -0,0 +1,157 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// BacktestCandidate is a monitor configuration which is evaluated over recorded stats.
+type BacktestCandidate struct {
+	Name                      string               `json:"name"`
+	AlertOnNetworkErrorStreak int                  `json:"streak"`
+	Criteria                  NetworkErrorCriteria `json:"criteria"`
+}
+
+// BacktestIncident is a period when the network would have been considered as degraded.
+// Recovered is zero if the network hasn't recovered till the end of the recording.
+type BacktestIncident struct {
+	Degraded  time.Time     `json:"degraded"`
+	Recovered time.Time     `json:"recovered"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// BacktestDifference is a period when candidate status differs from the baseline (first) candidate status.
+type BacktestDifference struct {
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	BaselineStatus  bool      `json:"baseline_status"`
+	CandidateStatus bool      `json:"candidate_status"`
+}
+
+type BacktestReport struct {
+	Candidate         BacktestCandidate    `json:"candidate"`
+	Incidents         []BacktestIncident   `json:"incidents"`
+	TotalDegraded     time.Duration        `json:"total_degraded"`
+	BaselineDiffs     []BacktestDifference `json:"baseline_diffs"`
+	statusesPerRecord []bool
+}
+
+// Backtest replays records through a separate monitor for each candidate and reports when the network would have
+// been degraded and recovered. The first candidate is treated as the baseline for the other ones.
+func Backtest(
+	records []StatsRecord,
+	netSchemeChar NetworkSchemeChar,
+	maxStatsHistoryLen int,
+	candidates []BacktestCandidate,
+) ([]BacktestReport, error) {
+	if len(records) == 0 {
+		return nil, errors.New("no stats records to backtest")
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("no candidates to backtest")
+	}
+	reports := make([]BacktestReport, 0, len(candidates))
+	for _, candidate := range candidates {
+		report, err := backtestCandidate(records, netSchemeChar, maxStatsHistoryLen, candidate)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to backtest candidate %q", candidate.Name)
+		}
+		reports = append(reports, report)
+	}
+	baseline := reports[0].statusesPerRecord
+	for i := 1; i < len(reports); i++ {
+		reports[i].BaselineDiffs = backtestDiffs(records, baseline, reports[i].statusesPerRecord)
+	}
+	return reports, nil
+}
+
+func backtestCandidate(
+	records []StatsRecord,
+	netSchemeChar NetworkSchemeChar,
+	maxStatsHistoryLen int,
+	candidate BacktestCandidate,
+) (BacktestReport, error) {
+	if err := candidate.Criteria.Validate(); err != nil {
+		return BacktestReport{}, err
+	}
+	replayer := NewStatsReplayer(records)
+	mon, err := NewNetworkMonitoring(
+		StateActive,
+		netSchemeChar,
+		maxStatsHistoryLen,
+		replayer,
+		candidate.AlertOnNetworkErrorStreak,
+		candidate.Criteria,
+	)
+	if err != nil {
+		return BacktestReport{}, err
+	}
+
+	report := BacktestReport{
+		Candidate:         candidate,
+		Incidents:         []BacktestIncident{},
+		BaselineDiffs:     []BacktestDifference{}, // the baseline candidate doesn't differ from itself
+		statusesPerRecord: make([]bool, 0, len(records)),
+	}
+	var incident *BacktestIncident
+	for {
+		record, err := replayer.Step(context.Background(), &mon)
+		if err != nil {
+			if errors.Is(err, ErrStatsReplayFinished) {
+				break
+			}
+			zap.S().Debugf("backtest %q: failed to check nodes status for record at %s: %v",
+				candidate.Name, record.Timestamp, err,
+			)
+		}
+		status := mon.NetworkOperatesStable()
+		report.statusesPerRecord = append(report.statusesPerRecord, status)
+		switch {
+		case !status && incident == nil:
+			incident = &BacktestIncident{Degraded: record.Timestamp}
+		case status && incident != nil:
+			incident.Recovered = record.Timestamp
+			incident.Duration = incident.Recovered.Sub(incident.Degraded)
+			report.Incidents = append(report.Incidents, *incident)
+			incident = nil
+		}
+	}
+	if incident != nil {
+		// network hasn't recovered till the end of the recording
+		incident.Duration = records[len(records)-1].Timestamp.Sub(incident.Degraded)
+		report.Incidents = append(report.Incidents, *incident)
+	}
+	for _, inc := range report.Incidents {
+		report.TotalDegraded += inc.Duration
+	}
+	return report, nil
+}
+
+func backtestDiffs(records []StatsRecord, baseline, candidate []bool) []BacktestDifference {
+	var (
+		diffs = []BacktestDifference{}
+		diff  *BacktestDifference
+	)
+	for i := range records {
+		switch {
+		case baseline[i] != candidate[i] && diff == nil:
+			diff = &BacktestDifference{
+				From:            records[i].Timestamp,
+				BaselineStatus:  baseline[i],
+				CandidateStatus: candidate[i],
+			}
+		case baseline[i] == candidate[i] && diff != nil:
+			diff.To = records[i].Timestamp
+			diffs = append(diffs, *diff)
+			diff = nil
+		}
+	}
+	if diff != nil {
+		diff.To = records[len(records)-1].Timestamp
+		diffs = append(diffs, *diff)
+	}
+	return diffs
+}
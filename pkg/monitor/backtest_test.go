@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBacktest(t *testing.T) {
+	start := time.Date(2021, 12, 2, 19, 0, 0, 0, time.UTC)
+	up := nodeWithStats{nodeStats: nodeStats{Height: 11, NetByte: MainNetSchemeChar}}
+	down := nodeWithStats{nodeStats: nodeStats{Height: -1, NetByte: MainNetSchemeChar}}
+	records := []StatsRecord{
+		{Timestamp: start, Nodes: nodesWithStats{up, up, up}},
+		{Timestamp: start.Add(1 * time.Minute), Nodes: nodesWithStats{up, up, down}},
+		{Timestamp: start.Add(2 * time.Minute), Nodes: nodesWithStats{up, up, down}},
+		{Timestamp: start.Add(3 * time.Minute), Nodes: nodesWithStats{up, up, up}},
+		{Timestamp: start.Add(4 * time.Minute), Nodes: nodesWithStats{up, down, down}},
+	}
+	criteria := NetworkErrorCriteria{
+		NodesDown:   NodesDownCriterion{TotalDownNodesPart: 0.3},
+		NodesHeight: NodesHeightCriterion{HeightDiff: 5, RequireMinNodesOnHeight: 2},
+		StateHash: NodesStateHashCriterion{
+			MinStateHashGroupsOnSameHeight:   2,
+			MinValuableStateHashGroups:       2,
+			MinNodesInValuableStateHashGroup: 2,
+			RequireMinNodesOnHeight:          4,
+		},
+	}
+	candidates := []BacktestCandidate{
+		{Name: "streak-1", AlertOnNetworkErrorStreak: 1, Criteria: criteria},
+		{Name: "streak-2", AlertOnNetworkErrorStreak: 2, Criteria: criteria},
+	}
+
+	reports, err := Backtest(records, MainNetSchemeChar, 10, candidates)
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+
+	require.Equal(t, []BacktestIncident{
+		{Degraded: start.Add(1 * time.Minute), Recovered: start.Add(3 * time.Minute), Duration: 2 * time.Minute},
+		{Degraded: start.Add(4 * time.Minute), Duration: 0},
+	}, reports[0].Incidents)
+	require.Equal(t, 2*time.Minute, reports[0].TotalDegraded)
+	require.Empty(t, reports[0].BaselineDiffs)
+	data, err := json.Marshal(reports[0])
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"baseline_diffs":[]`)
+
+	require.Equal(t, []BacktestIncident{
+		{Degraded: start.Add(2 * time.Minute), Recovered: start.Add(3 * time.Minute), Duration: time.Minute},
+	}, reports[1].Incidents)
+	require.Equal(t, []BacktestDifference{
+		{From: start.Add(1 * time.Minute), To: start.Add(2 * time.Minute), BaselineStatus: false, CandidateStatus: true},
+		{From: start.Add(4 * time.Minute), To: start.Add(4 * time.Minute), BaselineStatus: false, CandidateStatus: true},
+	}, reports[1].BaselineDiffs)
+
+	_, err = Backtest(nil, MainNetSchemeChar, 10, candidates)
+	require.Error(t, err)
+	_, err = Backtest(records, MainNetSchemeChar, 10, nil)
+	require.Error(t, err)
+	_, err = Backtest(records, MainNetSchemeChar, 10, []BacktestCandidate{{Name: "invalid", AlertOnNetworkErrorStreak: 1}})
+	require.Error(t, err)
+}
+
+func TestBacktestCandidate_JSON(t *testing.T) {
+	data := `{"name":"current","streak":5,"criteria":{
+		"nodes_down":{"total_down_nodes_part":0.3,"malformed_nodes":"down"},
+		"nodes_height":{"height_diff":5,"require_min_nodes_on_height":2},
+		"block_interval":{"expected":"1m","max_deviation":0.5,"window":"10m"},
+		"statehash":{"min_statehash_groups_on_same_height":2,"min_valuable_statehash_groups":2,
+			"min_nodes_in_valuable_statehash_group":2,"require_min_nodes_on_height":4},
+		"required_nodes":{"nodes":["n1"],"max_height_lag":3},
+		"node_weights":{"n1":2}
+	}}`
+	var candidate BacktestCandidate
+	require.NoError(t, json.Unmarshal([]byte(data), &candidate))
+	require.Equal(t, BacktestCandidate{
+		Name:                      "current",
+		AlertOnNetworkErrorStreak: 5,
+		Criteria: NetworkErrorCriteria{
+			NodesDown:     NodesDownCriterion{TotalDownNodesPart: 0.3, MalformedNodes: MalformedNodesAsDown},
+			NodesHeight:   NodesHeightCriterion{HeightDiff: 5, RequireMinNodesOnHeight: 2},
+			BlockInterval: BlockIntervalCriterion{Expected: time.Minute, MaxDeviation: 0.5, Window: 10 * time.Minute},
+			StateHash: NodesStateHashCriterion{
+				MinStateHashGroupsOnSameHeight:   2,
+				MinValuableStateHashGroups:       2,
+				MinNodesInValuableStateHashGroup: 2,
+				RequireMinNodesOnHeight:          4,
+			},
+			RequiredNodes: RequiredNodesCriterion{Nodes: []string{"n1"}, MaxHeightLag: 3},
+			NodeWeights:   map[string]float64{"n1": 2},
+		},
+	}, candidate)
+
+	encoded, err := json.Marshal(candidate)
+	require.NoError(t, err)
+	require.Contains(t, string(encoded), `"block_interval":{"expected":"1m0s","max_deviation":0.5,"window":"10m0s"}`)
+	var decoded BacktestCandidate
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	require.Equal(t, candidate, decoded)
+
+	err = json.Unmarshal([]byte(`{"criteria":{"block_interval":{"expected":"1 minute"}}}`), &decoded)
+	require.ErrorContains(t, err, "BlockIntervalCriterion.Expected")
+}
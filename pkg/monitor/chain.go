@@ -0,0 +1,169 @@
+package monitor
+
+import (
+	"encoding/json"
+	"expvar"
+	"time"
+)
+
+const DefaultExpectedBlockInterval = time.Minute
+
+// DefaultBlockRateWindows are the windows which are reported if WithBlockRateWindows option isn't set.
+var DefaultBlockRateWindows = []time.Duration{5 * time.Minute, 10 * time.Minute}
+
+// BlockRate is the observed block production rate over a window of the stats history.
+// Span is the actual time between the snapshots which are used for the calculation. If the history doesn't cover
+// the whole window yet, the oldest snapshot is used and Complete is false.
+type BlockRate struct {
+	Window           time.Duration `json:"window"`
+	Span             time.Duration `json:"span"`
+	Complete         bool          `json:"complete"`
+	Blocks           int           `json:"blocks"`
+	BlocksPerMinute  float64       `json:"blocks_per_minute"`
+	AvgBlockInterval time.Duration `json:"avg_block_interval"` // zero if no blocks have been produced
+}
+
+// MarshalJSON encodes durations in seconds.
+func (r BlockRate) MarshalJSON() ([]byte, error) {
+	type blockRate BlockRate
+	return json.Marshal(struct {
+		blockRate
+		Window           seconds `json:"window"`
+		Span             seconds `json:"span"`
+		AvgBlockInterval seconds `json:"avg_block_interval"`
+	}{blockRate(r), seconds(r.Window), seconds(r.Span), seconds(r.AvgBlockInterval)})
+}
+
+func (r *BlockRate) UnmarshalJSON(data []byte) error {
+	type blockRate BlockRate
+	aux := struct {
+		*blockRate
+		Window           seconds `json:"window"`
+		Span             seconds `json:"span"`
+		AvgBlockInterval seconds `json:"avg_block_interval"`
+	}{blockRate: (*blockRate)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Window, r.Span = time.Duration(aux.Window), time.Duration(aux.Span)
+	r.AvgBlockInterval = time.Duration(aux.AvgBlockInterval)
+	return nil
+}
+
+type NetworkChainInfo struct {
+	Updated               time.Time         `json:"updated,omitempty"`
+	Network               NetworkSchemeChar `json:"network"`
+	Height                int               `json:"height"`
+	ExpectedBlockInterval time.Duration     `json:"expected_block_interval"`
+	BlockIntervalAlert    bool              `json:"block_interval_alert"`
+	Rates                 []BlockRate       `json:"rates"`
+}
+
+// MarshalJSON encodes ExpectedBlockInterval in seconds.
+func (i NetworkChainInfo) MarshalJSON() ([]byte, error) {
+	type chainInfo NetworkChainInfo
+	return json.Marshal(struct {
+		chainInfo
+		ExpectedBlockInterval seconds `json:"expected_block_interval"`
+	}{chainInfo(i), seconds(i.ExpectedBlockInterval)})
+}
+
+func (i *NetworkChainInfo) UnmarshalJSON(data []byte) error {
+	type chainInfo NetworkChainInfo
+	aux := struct {
+		*chainInfo
+		ExpectedBlockInterval seconds `json:"expected_block_interval"`
+	}{chainInfo: (*chainInfo)(i)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	i.ExpectedBlockInterval = time.Duration(aux.ExpectedBlockInterval)
+	return nil
+}
+
+// blockRate calculates block rate over the window ending at the newest snapshot. Snapshots without working nodes
+// are skipped. It returns false if there are less than two suitable snapshots.
+func (d *statsHistoryDeque) blockRate(window time.Duration) (BlockRate, bool) {
+	var newest, oldest *statsDataSnapshot
+	for i := 0; i < d.Len(); i++ {
+		snapshot := d.At(i)
+		if snapshot.maxHeight <= 0 {
+			continue
+		}
+		if newest == nil {
+			newest = snapshot
+			continue
+		}
+		oldest = snapshot
+		if newest.snapshotCreationTime.Sub(snapshot.snapshotCreationTime) >= window {
+			break
+		}
+	}
+	if newest == nil || oldest == nil {
+		return BlockRate{}, false
+	}
+	rate := BlockRate{
+		Window: window,
+		Span:   newest.snapshotCreationTime.Sub(oldest.snapshotCreationTime),
+		Blocks: newest.maxHeight - oldest.maxHeight,
+	}
+	if rate.Span <= 0 {
+		return BlockRate{}, false
+	}
+	rate.Complete = rate.Span >= window
+	rate.BlocksPerMinute = float64(rate.Blocks) / rate.Span.Minutes()
+	if rate.Blocks > 0 {
+		rate.AvgBlockInterval = rate.Span / time.Duration(rate.Blocks)
+	}
+	return rate, true
+}
+
+// blockRates calculates block rates over the given windows, windows without enough snapshots are skipped.
+func (d *statsHistoryDeque) blockRates(windows []time.Duration) []BlockRate {
+	rates := make([]BlockRate, 0, len(windows))
+	for _, window := range windows {
+		if rate, ok := d.blockRate(window); ok {
+			rates = append(rates, rate)
+		}
+	}
+	return rates
+}
+
+// alertBlockIntervalCriterion checks the average block interval over the criterion window. The criterion isn't
+// generated until the stats history covers the whole window.
+func (d *statsHistoryDeque) alertBlockIntervalCriterion(criterion BlockIntervalCriterion) bool {
+	if !criterion.Enabled() {
+		return false
+	}
+	rate, ok := d.blockRate(criterion.Window)
+	if !ok || !rate.Complete {
+		return false
+	}
+	expected := criterion.Expected.Seconds()
+	var actual float64
+	if rate.Blocks > 0 {
+		actual = rate.AvgBlockInterval.Seconds()
+	} else {
+		actual = rate.Span.Seconds() // at least the whole span has passed without blocks
+		if actual <= expected {
+			return false
+		}
+	}
+	deviation := (actual - expected) / expected
+	if deviation < 0 {
+		deviation = -deviation
+	}
+	return deviation > criterion.MaxDeviation
+}
+
+func updateChainMetrics(height int, rates []BlockRate) {
+	metricChainHeight.Set(int64(height))
+	for _, rate := range rates {
+		interval := new(expvar.Float)
+		interval.Set(rate.AvgBlockInterval.Seconds())
+		metricChainAvgBlockIntervalSeconds.Set(rate.Window.String(), interval)
+		blocksPerMinute := new(expvar.Float)
+		blocksPerMinute.Set(rate.BlocksPerMinute)
+		metricChainBlocksPerMinute.Set(rate.Window.String(), blocksPerMinute)
+	}
+}
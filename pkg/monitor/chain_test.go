@@ -0,0 +1,91 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func pushHeights(d *statsHistoryDeque, start time.Time, step time.Duration, heights ...int) {
+	for i, height := range heights {
+		d.PushFront(&statsDataSnapshot{snapshotCreationTime: start.Add(time.Duration(i) * step), maxHeight: height})
+	}
+}
+
+func TestStatsHistoryDeque_BlockRates(t *testing.T) {
+	start := time.Date(2021, 12, 2, 19, 0, 0, 0, time.UTC)
+	history := newStatsDeque(10)
+	_, ok := history.blockRate(time.Minute)
+	require.False(t, ok)
+
+	pushHeights(&history, start, time.Minute, 100, 101, -1, 103, 104, 106, 108)
+
+	rates := history.blockRates([]time.Duration{2 * time.Minute, 3 * time.Minute, time.Hour})
+	require.Equal(t, []BlockRate{
+		{Window: 2 * time.Minute, Span: 2 * time.Minute, Complete: true, Blocks: 4, BlocksPerMinute: 2, AvgBlockInterval: 30 * time.Second},
+		{Window: 3 * time.Minute, Span: 3 * time.Minute, Complete: true, Blocks: 5, BlocksPerMinute: 5.0 / 3, AvgBlockInterval: 36 * time.Second},
+		{Window: time.Hour, Span: 6 * time.Minute, Complete: false, Blocks: 8, BlocksPerMinute: 8.0 / 6, AvgBlockInterval: 45 * time.Second},
+	}, rates)
+}
+
+func TestStatsHistoryDeque_AlertBlockIntervalCriterion(t *testing.T) {
+	start := time.Date(2021, 12, 2, 19, 0, 0, 0, time.UTC)
+	criterion := BlockIntervalCriterion{Expected: time.Minute, MaxDeviation: 0.5, Window: 4 * time.Minute}
+	tests := []struct {
+		heights   []int
+		criterion BlockIntervalCriterion
+		expected  bool
+	}{
+		{heights: []int{100, 101, 102, 103, 104}, criterion: criterion, expected: false},
+		{heights: []int{100, 101, 102, 103, 104}, criterion: BlockIntervalCriterion{}, expected: false},
+		{heights: []int{100, 101, 101, 102, 103}, criterion: criterion, expected: false}, // 80s interval
+		{heights: []int{100, 100, 100, 101, 101}, criterion: criterion, expected: true},  // 4m interval
+		{heights: []int{100, 100, 100, 100, 100}, criterion: criterion, expected: true},  // no blocks at all
+		{heights: []int{100, 105, 110, 115, 120}, criterion: criterion, expected: true},  // 12s interval
+		{heights: []int{100, 100, 100, 100}, criterion: criterion, expected: false},      // window isn't covered yet
+	}
+	for i, tc := range tests {
+		history := newStatsDeque(10)
+		pushHeights(&history, start, time.Minute, tc.heights...)
+		require.Equal(t, tc.expected, history.alertBlockIntervalCriterion(tc.criterion), "failed testcase #%d", i)
+	}
+}
+
+func TestBlockIntervalCriterion_Validate(t *testing.T) {
+	require.NoError(t, (&BlockIntervalCriterion{}).Validate())
+	require.NoError(t, (&BlockIntervalCriterion{Expected: time.Minute, MaxDeviation: 0.5, Window: 10 * time.Minute}).Validate())
+	require.Error(t, (&BlockIntervalCriterion{MaxDeviation: -1}).Validate())
+	require.Error(t, (&BlockIntervalCriterion{MaxDeviation: 0.5, Window: time.Minute}).Validate())
+	require.Error(t, (&BlockIntervalCriterion{Expected: time.Minute, MaxDeviation: 0.5, Window: time.Second}).Validate())
+}
+
+func TestNetworkMonitor_NetworkChainInfo(t *testing.T) {
+	mon, err := NewNetworkMonitoring(StateActive, MainNetSchemeChar, 10, nil, 5, NetworkErrorCriteria{},
+		WithBlockRateWindows(2*time.Minute),
+	)
+	require.NoError(t, err)
+	require.Equal(t, NetworkChainInfo{
+		Network:               MainNetSchemeChar,
+		Height:                -1,
+		ExpectedBlockInterval: DefaultExpectedBlockInterval,
+		Rates:                 []BlockRate{},
+	}, mon.NetworkChainInfo())
+
+	start := time.Date(2021, 12, 2, 19, 0, 0, 0, time.UTC)
+	pushHeights(&mon.statsHistory, start, time.Minute, 100, 101, 102)
+	require.Equal(t, NetworkChainInfo{
+		Updated:               start.Add(2 * time.Minute),
+		Network:               MainNetSchemeChar,
+		Height:                102,
+		ExpectedBlockInterval: DefaultExpectedBlockInterval,
+		Rates: []BlockRate{
+			{Window: 2 * time.Minute, Span: 2 * time.Minute, Complete: true, Blocks: 2, BlocksPerMinute: 1, AvgBlockInterval: time.Minute},
+		},
+	}, mon.NetworkChainInfo())
+
+	_, err = NewNetworkMonitoring(StateActive, MainNetSchemeChar, 10, nil, 5, NetworkErrorCriteria{},
+		WithBlockRateWindows(0),
+	)
+	require.Error(t, err)
+}
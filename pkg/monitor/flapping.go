@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"sort"
+	"time"
+)
+
+// FlappingNode is a node which has changed between working and down states in the stats history window.
+type FlappingNode struct {
+	Domain      string `json:"domain"`
+	Transitions int    `json:"transitions"`
+	Working     bool   `json:"working"`  // last known node state
+	Flapping    bool   `json:"flapping"` // transitions exceed flapping criterion
+}
+
+type NetworkFlappingInfo struct {
+	Updated   time.Time         `json:"updated,omitempty"`
+	Network   NetworkSchemeChar `json:"network"`
+	From      time.Time         `json:"from,omitempty"` // creation time of the oldest snapshot in the window
+	Snapshots int               `json:"snapshots"`
+	Nodes     []FlappingNode    `json:"nodes"`
+}
+
+// nodesTransitions returns nodes which have changed between working and down states in the stats history, sorted
+// by transitions count in descending order. Syncing and malformed states and missing nodes don't break the sequence,
+// so the last known state is kept for them.
+func (d *statsHistoryDeque) nodesTransitions(criterion NodesFlappingCriterion) []FlappingNode {
+	type nodeState struct {
+		working     bool
+		transitions int
+	}
+	states := make(map[string]*nodeState)
+	for i := d.Len() - 1; i >= 0; i-- { // from the oldest snapshot to the newest one
+		for _, node := range d.At(i).nodes {
+			var working bool
+			switch class, _ := node.Classify(); class {
+			case NodeClassValid:
+				working = true
+			case NodeClassDown:
+				working = false
+			default:
+				continue
+			}
+			state, ok := states[node.NodeDomain]
+			if !ok {
+				states[node.NodeDomain] = &nodeState{working: working}
+				continue
+			}
+			if state.working != working {
+				state.working = working
+				state.transitions++
+			}
+		}
+	}
+	var nodes []FlappingNode
+	for domain, state := range states {
+		if state.transitions == 0 {
+			continue
+		}
+		nodes = append(nodes, FlappingNode{
+			Domain:      domain,
+			Transitions: state.transitions,
+			Working:     state.working,
+			Flapping:    criterion.Enabled() && state.transitions > criterion.MaxTransitions,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Transitions != nodes[j].Transitions {
+			return nodes[i].Transitions > nodes[j].Transitions
+		}
+		return nodes[i].Domain < nodes[j].Domain
+	})
+	return nodes
+}
+
+func countFlapping(nodes []FlappingNode) int {
+	cnt := 0
+	for _, node := range nodes {
+		if node.Flapping {
+			cnt++
+		}
+	}
+	return cnt
+}
@@ -0,0 +1,102 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nickeskov/netmon/pkg/fakestats"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsHistoryDeque_NodesTransitions(t *testing.T) {
+	up := func(domain string) nodeWithStats {
+		return nodeWithStats{NodeDomain: domain, nodeStats: nodeStats{Height: 10, StateHash: "aa"}}
+	}
+	down := func(domain string) nodeWithStats {
+		return nodeWithStats{NodeDomain: domain, nodeStats: nodeStats{Height: -1}}
+	}
+	syncing := func(domain string) nodeWithStats {
+		return nodeWithStats{NodeDomain: domain, nodeStats: nodeStats{Height: 0}}
+	}
+	malformed := func(domain string) nodeWithStats {
+		return nodeWithStats{NodeDomain: domain, nodeStats: nodeStats{Height: 10}} // empty statehash
+	}
+	history := newStatsDeque(10)
+	for _, nodes := range []nodesWithStats{
+		{up("a"), up("b"), up("c"), down("d")},
+		{down("a"), up("b"), down("c"), malformed("d")}, // malformed "d" isn't working
+		{up("a"), syncing("b"), down("c"), down("d")},
+		{down("a"), down("b")}, // "c" is missing
+		{up("a"), down("b"), up("c")},
+	} {
+		history.PushFront(&statsDataSnapshot{nodes: nodes})
+	}
+
+	require.Equal(t, []FlappingNode{
+		{Domain: "a", Transitions: 4, Working: true, Flapping: true},
+		{Domain: "c", Transitions: 2, Working: true},
+		{Domain: "b", Transitions: 1, Working: false},
+	}, history.nodesTransitions(NodesFlappingCriterion{MaxTransitions: 2}))
+
+	require.Equal(t, []FlappingNode{
+		{Domain: "a", Transitions: 4, Working: true},
+		{Domain: "c", Transitions: 2, Working: true},
+		{Domain: "b", Transitions: 1, Working: false},
+	}, history.nodesTransitions(NodesFlappingCriterion{}))
+
+	empty := newStatsDeque(10)
+	require.Empty(t, empty.nodesTransitions(NodesFlappingCriterion{MaxTransitions: 1}))
+}
+
+func TestNetworkMonitor_CheckNodes_Flapping(t *testing.T) {
+	srv := fakestats.NewServer()
+	defer srv.Close()
+	srv.AddNodes(string(MainNetSchemeChar), 100, "aa", "n1", "n2", "n3", "n4", "n5", "n6", "n7", "n8")
+
+	mon, err := NewNetworkMonitoring(
+		StateActive,
+		MainNetSchemeChar,
+		5,
+		NewNodesStatsScraperHTTP(srv.URL(), DefaultNodeStatsPollResponseSize),
+		1,
+		NetworkErrorCriteria{
+			NodesDown:     NodesDownCriterion{TotalDownNodesPart: 0.3},
+			NodesHeight:   NodesHeightCriterion{HeightDiff: 5, RequireMinNodesOnHeight: 2},
+			NodesFlapping: NodesFlappingCriterion{MaxTransitions: 2},
+			StateHash: NodesStateHashCriterion{
+				MinStateHashGroupsOnSameHeight:   2,
+				MinValuableStateHashGroups:       2,
+				MinNodesInValuableStateHashGroup: 2,
+				RequireMinNodesOnHeight:          4,
+			},
+		},
+	)
+	require.NoError(t, err)
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		scenario func()
+		stable   bool
+	}{
+		{"AllNodesWork", func() {}, true},
+		{"NodeDown", func() { srv.NodesDown("n1") }, true},
+		{"NodeUp", func() { srv.SetHeight(100, "n1"); srv.Fork("aa", "n1") }, true},
+		{"NodeDownAgain", func() { srv.NodesDown("n1") }, false},
+		{"NodeUpAgain", func() { srv.SetHeight(100, "n1"); srv.Fork("aa", "n1") }, false},
+		{"NodeStable", func() {}, false},
+		{"OldTransitionsLeftWindow", func() {}, true},
+	}
+	for i, tc := range tests {
+		tc.scenario()
+		require.NoError(t, mon.CheckNodes(context.Background(), now.Add(time.Duration(i)*time.Minute)), "failed testcase %q", tc.name)
+		require.Equal(t, tc.stable, mon.NetworkOperatesStable(), "failed testcase %q", tc.name)
+	}
+
+	info := mon.NetworkFlappingInfo()
+	require.Equal(t, now.Add(6*time.Minute), info.Updated)
+	require.Equal(t, now.Add(2*time.Minute), info.From)
+	require.Equal(t, 5, info.Snapshots)
+	require.Equal(t, []FlappingNode{{Domain: "n1", Transitions: 2, Working: true}}, info.Nodes)
+}
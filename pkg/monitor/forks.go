@@ -0,0 +1,183 @@
+package monitor
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// ForkNode is a node which belongs to a statehash group.
+type ForkNode struct {
+	Domain  string `json:"domain"`
+	Version string `json:"version"`
+}
+
+// ForkGroup is a group of nodes on the same height with the same statehash.
+type ForkGroup struct {
+	StateHash string     `json:"statehash"`
+	Weight    float64    `json:"weight"`
+	Versions  []string   `json:"versions"`
+	Nodes     []ForkNode `json:"nodes"`
+}
+
+// Fork is a split of working nodes on the same height into several statehash groups.
+// Since is the creation time of the oldest consecutive stats snapshot in which any of the current minority nodes
+// has been split from the majority, so the duration is limited by the stats history size.
+type Fork struct {
+	Height            int           `json:"height"`
+	Groups            []ForkGroup   `json:"groups"`
+	MajorityStateHash string        `json:"majority_statehash"`
+	MinorityNodes     []ForkNode    `json:"minority_nodes"`
+	Since             time.Time     `json:"since"`
+	Duration          time.Duration `json:"duration"`
+}
+
+// MarshalJSON encodes Duration in seconds.
+func (f Fork) MarshalJSON() ([]byte, error) {
+	type fork Fork
+	return json.Marshal(struct {
+		fork
+		Duration seconds `json:"duration"`
+	}{fork(f), seconds(f.Duration)})
+}
+
+func (f *Fork) UnmarshalJSON(data []byte) error {
+	type fork Fork
+	aux := struct {
+		*fork
+		Duration seconds `json:"duration"`
+	}{fork: (*fork)(f)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	f.Duration = time.Duration(aux.Duration)
+	return nil
+}
+
+type NetworkForksInfo struct {
+	Updated            time.Time         `json:"updated,omitempty"`
+	Network            NetworkSchemeChar `json:"network"`
+	StateHashCriterion bool              `json:"statehash_criterion"`
+	Forks              []Fork            `json:"forks"`
+}
+
+// nodesWeight returns summed weight of the nodes. Nodes which are absent in weights have weight 1.
+func nodesWeight(nodes nodesWithStats, weights map[string]float64) float64 {
+	if len(weights) == 0 {
+		return float64(len(nodes))
+	}
+	total := 0.0
+	for _, node := range nodes {
+		if w, ok := weights[node.NodeDomain]; ok {
+			total += w
+		} else {
+			total += 1
+		}
+	}
+	return total
+}
+
+// findForks returns forks sorted by height in descending order. Groups are sorted by weight in descending order,
+// so the first group is the majority one. Since and Duration fields aren't filled.
+func findForks(nodes nodesWithStats, weights map[string]float64) []Fork {
+	var forks []Fork
+	for height, nodesOnHeight := range nodes.WorkingNodes().SplitByHeight() {
+		splitByStateHash := nodesOnHeight.SplitByStateHash()
+		if len(splitByStateHash) < 2 {
+			continue
+		}
+		fork := Fork{Height: height, Groups: make([]ForkGroup, 0, len(splitByStateHash))}
+		for stateHash, groupNodes := range splitByStateHash {
+			fork.Groups = append(fork.Groups, newForkGroup(stateHash, groupNodes, weights))
+		}
+		sort.Slice(fork.Groups, func(i, j int) bool {
+			if fork.Groups[i].Weight != fork.Groups[j].Weight {
+				return fork.Groups[i].Weight > fork.Groups[j].Weight
+			}
+			return fork.Groups[i].StateHash < fork.Groups[j].StateHash
+		})
+		fork.MajorityStateHash = fork.Groups[0].StateHash
+		for _, group := range fork.Groups[1:] {
+			fork.MinorityNodes = append(fork.MinorityNodes, group.Nodes...)
+		}
+		sort.Slice(fork.MinorityNodes, func(i, j int) bool {
+			return fork.MinorityNodes[i].Domain < fork.MinorityNodes[j].Domain
+		})
+		forks = append(forks, fork)
+	}
+	sort.Slice(forks, func(i, j int) bool {
+		return forks[i].Height > forks[j].Height
+	})
+	return forks
+}
+
+func newForkGroup(stateHash string, nodes nodesWithStats, weights map[string]float64) ForkGroup {
+	group := ForkGroup{
+		StateHash: stateHash,
+		Weight:    nodesWeight(nodes, weights),
+		Versions:  make([]string, 0, 1),
+		Nodes:     make([]ForkNode, 0, len(nodes)),
+	}
+	for version := range nodes.SplitByVersion() {
+		group.Versions = append(group.Versions, version)
+	}
+	sort.Strings(group.Versions)
+	for _, node := range nodes {
+		group.Nodes = append(group.Nodes, ForkNode{Domain: node.NodeDomain, Version: node.Version})
+	}
+	sort.Slice(group.Nodes, func(i, j int) bool {
+		return group.Nodes[i].Domain < group.Nodes[j].Domain
+	})
+	return group
+}
+
+func minorityDomains(forks []Fork) map[string]struct{} {
+	domains := make(map[string]struct{})
+	for _, fork := range forks {
+		for _, node := range fork.MinorityNodes {
+			domains[node.Domain] = struct{}{}
+		}
+	}
+	return domains
+}
+
+// unsafeForks finds forks in the latest snapshot and calculates how long they've lasted. Caller must hold the lock.
+func (m *NetworkMonitor) unsafeForks() []Fork {
+	if m.statsHistory.Len() == 0 {
+		return nil
+	}
+	front := m.statsHistory.Front()
+	forks := findForks(front.nodes, m.criteria.NodeWeights)
+	if len(forks) == 0 {
+		return nil
+	}
+	// minority nodes of previous snapshots are calculated lazily and only once
+	previousMinorities := make([]map[string]struct{}, 0, m.statsHistory.Len()-1)
+	for i := range forks {
+		fork := &forks[i]
+		fork.Since = front.snapshotCreationTime
+		for j := 1; j < m.statsHistory.Len(); j++ {
+			if len(previousMinorities) < j {
+				snapshot := m.statsHistory.At(j)
+				previousMinorities = append(previousMinorities,
+					minorityDomains(findForks(snapshot.nodes, m.criteria.NodeWeights)),
+				)
+			}
+			if !anyNodeIn(fork.MinorityNodes, previousMinorities[j-1]) {
+				break
+			}
+			fork.Since = m.statsHistory.At(j).snapshotCreationTime
+		}
+		fork.Duration = front.snapshotCreationTime.Sub(fork.Since)
+	}
+	return forks
+}
+
+func anyNodeIn(nodes []ForkNode, domains map[string]struct{}) bool {
+	for _, node := range nodes {
+		if _, ok := domains[node.Domain]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func forkTestNode(domain string, height int, stateHash, version string) nodeWithStats {
+	return nodeWithStats{
+		NodeDomain: domain,
+		nodeStats:  nodeStats{NetByte: MainNetSchemeChar, Height: height, StateHash: stateHash, Version: version},
+	}
+}
+
+func TestFindForks(t *testing.T) {
+	nodes := nodesWithStats{
+		forkTestNode("a", 10, "aa", "1.4.1"),
+		forkTestNode("b", 10, "aa", "1.4.2"),
+		forkTestNode("c", 10, "bb", "1.4.0"),
+		forkTestNode("d", 10, "", "1.4.0"), // malformed
+		forkTestNode("e", 9, "cc", "1.4.2"),
+		forkTestNode("f", -1, "", ""),
+	}
+	require.Empty(t, findForks(nodes[:2], nil))
+
+	forks := findForks(nodes, nil)
+	require.Equal(t, []Fork{
+		{
+			Height: 10,
+			Groups: []ForkGroup{
+				{StateHash: "aa", Weight: 2, Versions: []string{"1.4.1", "1.4.2"}, Nodes: []ForkNode{{"a", "1.4.1"}, {"b", "1.4.2"}}},
+				{StateHash: "bb", Weight: 1, Versions: []string{"1.4.0"}, Nodes: []ForkNode{{"c", "1.4.0"}}},
+			},
+			MajorityStateHash: "aa",
+			MinorityNodes:     []ForkNode{{"c", "1.4.0"}},
+		},
+	}, forks)
+
+	// weighted node changes the majority
+	forks = findForks(nodes, map[string]float64{"c": 3})
+	require.Len(t, forks, 1)
+	require.Equal(t, "bb", forks[0].MajorityStateHash)
+	require.Equal(t, []ForkNode{{"a", "1.4.1"}, {"b", "1.4.2"}}, forks[0].MinorityNodes)
+}
+
+func TestNetworkMonitor_NetworkForksInfo(t *testing.T) {
+	mon, err := NewNetworkMonitoring(StateActive, MainNetSchemeChar, 10, nil, 5, NetworkErrorCriteria{})
+	require.NoError(t, err)
+	require.Equal(t, NetworkForksInfo{Network: MainNetSchemeChar, Forks: []Fork{}}, mon.NetworkForksInfo())
+
+	start := time.Date(2021, 12, 2, 19, 0, 0, 0, time.UTC)
+	snapshots := []nodesWithStats{
+		{forkTestNode("a", 9, "aa", "1"), forkTestNode("b", 9, "aa", "1"), forkTestNode("c", 9, "aa", "1")},
+		{forkTestNode("a", 10, "aa", "1"), forkTestNode("b", 10, "aa", "1"), forkTestNode("c", 10, "bb", "1")},
+		{forkTestNode("a", 11, "aa", "1"), forkTestNode("b", 11, "aa", "1"), forkTestNode("c", 11, "bb", "1")},
+		{forkTestNode("a", 12, "aa", "1"), forkTestNode("b", 12, "aa", "1"), forkTestNode("c", 12, "bb", "1")},
+	}
+	for i, nodes := range snapshots {
+		mon.statsHistory.PushFront(&statsDataSnapshot{
+			snapshotCreationTime: start.Add(time.Duration(i) * time.Minute),
+			nodes:                nodes,
+			stateHashCriterion:   i > 0,
+		})
+	}
+
+	info := mon.NetworkForksInfo()
+	require.Equal(t, start.Add(3*time.Minute), info.Updated)
+	require.True(t, info.StateHashCriterion)
+	require.Len(t, info.Forks, 1)
+	require.Equal(t, 12, info.Forks[0].Height)
+	require.Equal(t, []ForkNode{{"c", "1"}}, info.Forks[0].MinorityNodes)
+	require.Equal(t, start.Add(time.Minute), info.Forks[0].Since)
+	require.Equal(t, 2*time.Minute, info.Forks[0].Duration)
+}
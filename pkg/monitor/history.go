@@ -0,0 +1,53 @@
+package monitor
+
+import "time"
+
+// HistoryPoint is the summary of the stats snapshot. Working nodes are nodes of the valid class, i.e. neither down,
+// syncing nor malformed, see nodeWithStats.Classify.
+type HistoryPoint struct {
+	Timestamp    time.Time `json:"timestamp"`
+	Height       int       `json:"height"`
+	Nodes        int       `json:"nodes"`
+	WorkingNodes int       `json:"working_nodes"`
+	Alerted      bool      `json:"alerted"`
+	Criteria     []string  `json:"criteria"`
+}
+
+// NetworkHistoryInfo contains points of the kept stats snapshots from the oldest to the newest one.
+type NetworkHistoryInfo struct {
+	Updated time.Time         `json:"updated,omitempty"`
+	Network NetworkSchemeChar `json:"network"`
+	Points  []HistoryPoint    `json:"points"`
+}
+
+func (m *NetworkMonitor) NetworkHistoryInfo() NetworkHistoryInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	historyInfo := NetworkHistoryInfo{
+		Network: m.netSchemeChar,
+		Points:  make([]HistoryPoint, 0, m.statsHistory.Len()),
+	}
+	for i := m.statsHistory.Len() - 1; i >= 0; i-- {
+		historyInfo.Points = append(historyInfo.Points, newHistoryPoint(m.statsHistory.At(i)))
+	}
+	if m.statsHistory.Len() != 0 {
+		historyInfo.Updated = m.statsHistory.Front().snapshotCreationTime
+	}
+	return historyInfo
+}
+
+func newHistoryPoint(snapshot *statsDataSnapshot) HistoryPoint {
+	point := HistoryPoint{
+		Timestamp: snapshot.snapshotCreationTime,
+		Height:    snapshot.maxHeight,
+		Nodes:     len(snapshot.nodes),
+		Alerted:   snapshot.anyCriterionAlerted(),
+		Criteria:  snapshot.alertedCriteria(),
+	}
+	if point.Criteria == nil {
+		point.Criteria = []string{}
+	}
+	point.WorkingNodes = len(snapshot.nodes.WorkingNodes())
+	return point
+}
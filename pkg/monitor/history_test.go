@@ -0,0 +1,63 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nickeskov/netmon/pkg/clock/clocktest"
+	"github.com/nickeskov/netmon/pkg/fakestats"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkMonitor_NetworkHistoryInfo(t *testing.T) {
+	srv := fakestats.NewServer()
+	defer srv.Close()
+	srv.AddNodes(string(MainNetSchemeChar), 100, "aa", "n1", "n2", "n3", "n4")
+
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	clk := clocktest.NewFakeClock(start)
+	mon, err := NewNetworkMonitoring(
+		StateActive,
+		MainNetSchemeChar,
+		2,
+		NewNodesStatsScraperHTTP(srv.URL(), DefaultNodeStatsPollResponseSize),
+		2,
+		NetworkErrorCriteria{
+			NodesDown:   NodesDownCriterion{TotalDownNodesPart: 0.3},
+			NodesHeight: NodesHeightCriterion{HeightDiff: 5, RequireMinNodesOnHeight: 2},
+			StateHash: NodesStateHashCriterion{
+				MinStateHashGroupsOnSameHeight:   2,
+				MinValuableStateHashGroups:       2,
+				MinNodesInValuableStateHashGroup: 2,
+				RequireMinNodesOnHeight:          4,
+			},
+		},
+		WithClock(clk),
+	)
+	require.NoError(t, err)
+
+	info := mon.NetworkHistoryInfo()
+	require.Equal(t, NetworkHistoryInfo{Network: MainNetSchemeChar, Points: []HistoryPoint{}}, info)
+
+	check := func() {
+		require.NoError(t, mon.CheckNodes(context.Background(), clk.Now()))
+		clk.Advance(time.Minute)
+	}
+	check()
+	srv.SetHeight(101, "n1", "n2", "n3", "n4")
+	check()
+	srv.NodesDown("n1", "n2")
+	check() // the oldest snapshot is dropped
+
+	info = mon.NetworkHistoryInfo()
+	require.Equal(t, NetworkHistoryInfo{
+		Updated: start.Add(2 * time.Minute),
+		Network: MainNetSchemeChar,
+		Points: []HistoryPoint{
+			{Timestamp: start.Add(time.Minute), Height: 101, Nodes: 4, WorkingNodes: 4, Criteria: []string{}},
+			{Timestamp: start.Add(2 * time.Minute), Height: 101, Nodes: 4, WorkingNodes: 2, Alerted: true,
+				Criteria: []string{CriterionNodesDown}},
+		},
+	}, info)
+}
@@ -0,0 +1,251 @@
+package monitor
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/gammazero/deque"
+	"github.com/pkg/errors"
+)
+
+var ErrIncidentNotFound = errors.New("incident not found")
+
+// IncidentStateChange is the manual monitor state change which has been made during the incident.
+type IncidentStateChange struct {
+	Timestamp time.Time `json:"timestamp"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+}
+
+type IncidentNote struct {
+	Timestamp time.Time `json:"timestamp"`
+	Author    string    `json:"author,omitempty"`
+	Text      string    `json:"text"`
+}
+
+// Incident is the period when the network hasn't been operating stable. It opens when the network becomes degraded,
+// either by criteria or manually, and closes when the network recovers. Criteria and nodes include the error streak
+// which has opened the incident. Duration of the ongoing incident is calculated till the current time.
+type Incident struct {
+	ID           int                   `json:"id"`
+	Start        time.Time             `json:"start"`
+	End          time.Time             `json:"end,omitempty"`
+	Ongoing      bool                  `json:"ongoing"`
+	Duration     time.Duration         `json:"duration"`
+	PeakSeverity Severity              `json:"peak_severity"`
+	Criteria     []string              `json:"criteria"`
+	Nodes        []string              `json:"nodes"`
+	StateChanges []IncidentStateChange `json:"state_changes"`
+	Notes        []IncidentNote        `json:"notes"`
+}
+
+// MarshalJSON encodes Duration in seconds.
+func (i Incident) MarshalJSON() ([]byte, error) {
+	type incident Incident
+	return json.Marshal(struct {
+		incident
+		Duration seconds `json:"duration"`
+	}{incident(i), seconds(i.Duration)})
+}
+
+func (i *Incident) UnmarshalJSON(data []byte) error {
+	type incident Incident
+	aux := struct {
+		*incident
+		Duration seconds `json:"duration"`
+	}{incident: (*incident)(i)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	i.Duration = time.Duration(aux.Duration)
+	return nil
+}
+
+type NetworkIncidentsInfo struct {
+	Network   NetworkSchemeChar `json:"network"`
+	Incidents []Incident        `json:"incidents"`
+}
+
+// DefaultMaxIncidents is the default number of the latest incidents which are kept in memory.
+const DefaultMaxIncidents = 1000
+
+// incidentsLog keeps the latest incidents in memory ordered by ID, only the latest incident can be ongoing.
+// The oldest incident is dropped when a new one is opened and the log is full.
+type incidentsLog struct {
+	maxLen    int
+	lastID    int
+	incidents *deque.Deque[*Incident] // from the oldest to the latest one
+	criteria  map[string]struct{}     // criteria of the ongoing incident
+	nodes     map[string]struct{}     // nodes of the ongoing incident
+}
+
+func newIncidentsLog(maxLen int) incidentsLog {
+	return incidentsLog{
+		maxLen:    maxLen,
+		incidents: deque.New[*Incident](),
+	}
+}
+
+func (l *incidentsLog) ongoing() *Incident {
+	if l.incidents.Len() != 0 && l.incidents.Back().Ongoing {
+		return l.incidents.Back()
+	}
+	return nil
+}
+
+func (l *incidentsLog) open(now time.Time) *Incident {
+	if l.incidents.Len() >= l.maxLen {
+		l.incidents.PopFront()
+	}
+	l.lastID++
+	incident := &Incident{
+		ID:           l.lastID,
+		Start:        now,
+		Ongoing:      true,
+		PeakSeverity: SeverityOK,
+		Criteria:     []string{},
+		Nodes:        []string{},
+		StateChanges: []IncidentStateChange{},
+		Notes:        []IncidentNote{},
+	}
+	l.incidents.PushBack(incident)
+	l.criteria = make(map[string]struct{})
+	l.nodes = make(map[string]struct{})
+	return incident
+}
+
+func (l *incidentsLog) close(now time.Time) {
+	incident := l.ongoing()
+	if incident == nil {
+		return
+	}
+	incident.End = now
+	incident.Ongoing = false
+	incident.Duration = now.Sub(incident.Start)
+	l.criteria, l.nodes = nil, nil
+}
+
+// find returns the kept incident by ID or nil.
+func (l *incidentsLog) find(id int) *Incident {
+	if l.incidents.Len() == 0 {
+		return nil
+	}
+	i := id - l.incidents.Front().ID
+	if i < 0 || i >= l.incidents.Len() {
+		return nil
+	}
+	return l.incidents.At(i)
+}
+
+// observe adds alerted criteria, their severity and involved nodes to the ongoing incident.
+func (l *incidentsLog) observe(severity Severity, criteria, nodes []string) {
+	incident := l.ongoing()
+	if incident == nil {
+		return
+	}
+	incident.PeakSeverity = max(incident.PeakSeverity, severity)
+	for _, criterion := range criteria {
+		l.criteria[criterion] = struct{}{}
+	}
+	for _, node := range nodes {
+		l.nodes[node] = struct{}{}
+	}
+	incident.Criteria = sortedKeys(l.criteria)
+	incident.Nodes = sortedKeys(l.nodes)
+}
+
+// stateChanged adds the manual state change to the latest incident if it's ongoing or the change has closed it,
+// the network is degraded at least as the operator has declared. It must be called after the incident is tracked,
+// so the change which has opened the incident is added to it too.
+func (l *incidentsLog) stateChanged(now time.Time, from, to NetworkMonitoringState) {
+	if l.incidents.Len() == 0 {
+		return
+	}
+	incident := l.incidents.Back()
+	if !incident.Ongoing && !incident.End.Equal(now) {
+		return
+	}
+	incident.StateChanges = append(incident.StateChanges,
+		IncidentStateChange{Timestamp: now, From: from.String(), To: to.String()},
+	)
+	if to == StateFrozenNetworkDegraded {
+		incident.PeakSeverity = max(incident.PeakSeverity, SeverityDegraded)
+	}
+}
+
+func (l *incidentsLog) addNote(id int, note IncidentNote) (Incident, error) {
+	incident := l.find(id)
+	if incident == nil {
+		return Incident{}, ErrIncidentNotFound
+	}
+	incident.Notes = append(incident.Notes, note)
+	return copyIncident(*incident), nil
+}
+
+func (l *incidentsLog) get(id int, now time.Time) (Incident, bool) {
+	found := l.find(id)
+	if found == nil {
+		return Incident{}, false
+	}
+	incident := copyIncident(*found)
+	if incident.Ongoing {
+		incident.Duration = now.Sub(incident.Start)
+	}
+	return incident, true
+}
+
+// list returns incidents from the newest to the oldest one.
+func (l *incidentsLog) list(now time.Time) []Incident {
+	incidents := make([]Incident, 0, l.incidents.Len())
+	for i := l.incidents.Len() - 1; i >= 0; i-- {
+		incident, _ := l.get(l.incidents.At(i).ID, now)
+		incidents = append(incidents, incident)
+	}
+	return incidents
+}
+
+// copyIncident copies slices of the incident, so the copy isn't affected by further updates.
+func copyIncident(incident Incident) Incident {
+	incident.Criteria = append([]string{}, incident.Criteria...)
+	incident.Nodes = append([]string{}, incident.Nodes...)
+	incident.StateChanges = append([]IncidentStateChange{}, incident.StateChanges...)
+	incident.Notes = append([]IncidentNote{}, incident.Notes...)
+	return incident
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// involvedNodes returns domains of the nodes which may cause alerts: down, syncing, malformed, lagging nodes,
+// nodes from minority statehash groups and failed required nodes.
+func involvedNodes(criteria NetworkErrorCriteria, nodes nodesWithStats) []string {
+	calc, err := newNetstatCalculator(criteria, nodes)
+	if err != nil {
+		return nil
+	}
+	set := make(map[string]struct{})
+	for _, group := range []nodesWithStats{calc.downNodes, calc.syncingNodes, calc.malformedNodes} {
+		for _, node := range group {
+			set[node.NodeDomain] = struct{}{}
+		}
+	}
+	if criteria.NodesLag.Enabled() {
+		for _, lag := range calc.LaggingNodes() {
+			set[lag.Domain] = struct{}{}
+		}
+	}
+	for domain := range minorityDomains(findForks(nodes, criteria.NodeWeights)) {
+		set[domain] = struct{}{}
+	}
+	for _, domain := range calc.FailedRequiredNodes() {
+		set[domain] = struct{}{}
+	}
+	return sortedKeys(set)
+}
@@ -0,0 +1,147 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nickeskov/netmon/pkg/clock/clocktest"
+	"github.com/nickeskov/netmon/pkg/fakestats"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkMonitor_Incidents(t *testing.T) {
+	srv := fakestats.NewServer()
+	defer srv.Close()
+	srv.AddNodes(string(MainNetSchemeChar), 100, "aa", "n1", "n2", "n3", "n4")
+
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	clk := clocktest.NewFakeClock(start)
+	mon, err := NewNetworkMonitoring(
+		StateActive,
+		MainNetSchemeChar,
+		10,
+		NewNodesStatsScraperHTTP(srv.URL(), DefaultNodeStatsPollResponseSize),
+		2,
+		NetworkErrorCriteria{
+			NodesDown:   NodesDownCriterion{TotalDownNodesPart: 0.3},
+			NodesHeight: NodesHeightCriterion{HeightDiff: 5, RequireMinNodesOnHeight: 2},
+			StateHash: NodesStateHashCriterion{
+				MinStateHashGroupsOnSameHeight:   2,
+				MinValuableStateHashGroups:       2,
+				MinNodesInValuableStateHashGroup: 2,
+				RequireMinNodesOnHeight:          4,
+			},
+		},
+		WithClock(clk),
+	)
+	require.NoError(t, err)
+
+	check := func() {
+		require.NoError(t, mon.CheckNodes(context.Background(), clk.Now()))
+		clk.Advance(time.Minute)
+	}
+	check() // 00:00 operational
+	srv.NodesDown("n1", "n2")
+	check() // 00:01 error streak has started
+	require.Empty(t, mon.NetworkIncidentsInfo().Incidents)
+	check() // 00:02 incident #1 is opened
+	srv.SetHeight(100, "n1", "n2")
+	srv.Fork("aa", "n1", "n2")
+	srv.Fork("bb", "n3", "n4")
+	check() // 00:03 statehash fork
+
+	incident, err := mon.NetworkIncident(1)
+	require.NoError(t, err)
+	require.Equal(t, Incident{
+		ID:           1,
+		Start:        start.Add(2 * time.Minute),
+		Ongoing:      true,
+		Duration:     2 * time.Minute,
+		PeakSeverity: SeverityCritical,
+		Criteria:     []string{CriterionNodesDown, CriterionStateHash},
+		Nodes:        []string{"n1", "n2", "n3", "n4"},
+		StateChanges: []IncidentStateChange{},
+		Notes:        []IncidentNote{},
+	}, incident)
+
+	mon.ChangeState(StateFrozenNetworkDegraded) // 00:04
+	clk.Advance(time.Minute)
+	mon.ChangeState(StateActive) // 00:05 incident #1 is closed
+	clk.Advance(time.Minute)
+	mon.ChangeState(StateFrozenNetworkDegraded) // 00:06 incident #2 is opened manually
+	clk.Advance(time.Minute)
+
+	incident, err = mon.AddIncidentNote(1, "ops", "fork of n3 and n4")
+	require.NoError(t, err)
+	require.Equal(t, []IncidentNote{{Timestamp: start.Add(7 * time.Minute), Author: "ops", Text: "fork of n3 and n4"}},
+		incident.Notes,
+	)
+	_, err = mon.AddIncidentNote(3, "ops", "unknown")
+	require.ErrorIs(t, err, ErrIncidentNotFound)
+	_, err = mon.NetworkIncident(0)
+	require.ErrorIs(t, err, ErrIncidentNotFound)
+
+	info := mon.NetworkIncidentsInfo()
+	require.Equal(t, MainNetSchemeChar, info.Network)
+	require.Len(t, info.Incidents, 2)
+	require.Equal(t, Incident{
+		ID:           2,
+		Start:        start.Add(6 * time.Minute),
+		Ongoing:      true,
+		Duration:     time.Minute,
+		PeakSeverity: SeverityDegraded,
+		Criteria:     []string{},
+		Nodes:        []string{},
+		StateChanges: []IncidentStateChange{
+			{Timestamp: start.Add(6 * time.Minute), From: "active", To: "frozen_degraded"},
+		},
+		Notes: []IncidentNote{},
+	}, info.Incidents[0])
+	closed := info.Incidents[1]
+	require.Equal(t, start.Add(5*time.Minute), closed.End)
+	require.False(t, closed.Ongoing)
+	require.Equal(t, 3*time.Minute, closed.Duration)
+	require.Equal(t, []IncidentStateChange{
+		{Timestamp: start.Add(4 * time.Minute), From: "active", To: "frozen_degraded"},
+		{Timestamp: start.Add(5 * time.Minute), From: "frozen_degraded", To: "active"},
+	}, closed.StateChanges)
+	require.Len(t, closed.Notes, 1)
+}
+
+func TestIncidentsLog_PeakSeverity(t *testing.T) {
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	l := newIncidentsLog(DefaultMaxIncidents)
+	require.Equal(t, SeverityOK, l.open(start).PeakSeverity)
+	l.observe(SeverityWarning, []string{CriterionNodesLag}, []string{"n1"})
+	require.Equal(t, SeverityWarning, l.ongoing().PeakSeverity, "incident of warning criteria only")
+	l.stateChanged(start.Add(time.Minute), StateActive, StateFrozenNetworkOperatesStable)
+	require.Equal(t, SeverityWarning, l.ongoing().PeakSeverity)
+	l.stateChanged(start.Add(2*time.Minute), StateFrozenNetworkOperatesStable, StateFrozenNetworkDegraded)
+	require.Equal(t, SeverityDegraded, l.ongoing().PeakSeverity)
+	l.observe(SeverityCritical, []string{CriterionStateHash}, nil)
+	require.Equal(t, SeverityCritical, l.ongoing().PeakSeverity)
+}
+
+func TestIncidentsLog_MaxLen(t *testing.T) {
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	l := newIncidentsLog(2)
+	for i := 0; i < 3; i++ {
+		l.open(start.Add(time.Duration(i) * time.Hour))
+		l.close(start.Add(time.Duration(i)*time.Hour + time.Minute))
+	}
+	incidents := l.list(start.Add(4 * time.Hour))
+	require.Len(t, incidents, 2)
+	require.Equal(t, 3, incidents[0].ID)
+	require.Equal(t, 2, incidents[1].ID)
+
+	_, ok := l.get(1, start)
+	require.False(t, ok, "the oldest incident has been dropped")
+	_, err := l.addNote(1, IncidentNote{Text: "note"})
+	require.ErrorIs(t, err, ErrIncidentNotFound)
+	incident, err := l.addNote(2, IncidentNote{Text: "note"})
+	require.NoError(t, err)
+	require.Equal(t, start.Add(time.Hour), incident.Start)
+	_, ok = l.get(4, start)
+	require.False(t, ok)
+}
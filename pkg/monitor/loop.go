@@ -0,0 +1,58 @@
+package monitor
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// LoopInfo is the state of the monitor loop which is used by liveness and readiness probes of the service.
+// Zero times mean that the event hasn't happened yet, ScrapeStarted is zero if there's no ongoing scrape.
+type LoopInfo struct {
+	Started              time.Time     `json:"started,omitempty"`
+	LastIteration        time.Time     `json:"last_iteration,omitempty"`
+	PollInterval         time.Duration `json:"poll_interval"`
+	ScrapeStarted        time.Time     `json:"scrape_started,omitempty"`
+	LastSuccessfulScrape time.Time     `json:"last_successful_scrape,omitempty"`
+	State                string        `json:"state"`
+}
+
+// MarshalJSON encodes PollInterval in seconds.
+func (l LoopInfo) MarshalJSON() ([]byte, error) {
+	type loopInfo LoopInfo
+	return json.Marshal(struct {
+		loopInfo
+		PollInterval seconds `json:"poll_interval"`
+	}{loopInfo(l), seconds(l.PollInterval)})
+}
+
+func (l *LoopInfo) UnmarshalJSON(data []byte) error {
+	type loopInfo LoopInfo
+	aux := struct {
+		*loopInfo
+		PollInterval seconds `json:"poll_interval"`
+	}{loopInfo: (*loopInfo)(l)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	l.PollInterval = time.Duration(aux.PollInterval)
+	return nil
+}
+
+// LoopInfo doesn't wait for the ongoing scrape, so it's safe to call it from probes.
+func (m *NetworkMonitor) LoopInfo() LoopInfo {
+	return LoopInfo{
+		Started:              unixNanoTime(m.loopStarted.Load()),
+		LastIteration:        unixNanoTime(m.loopIteration.Load()),
+		PollInterval:         time.Duration(m.loopPollInterval.Load()),
+		ScrapeStarted:        unixNanoTime(m.scrapeStarted.Load()),
+		LastSuccessfulScrape: unixNanoTime(m.lastSuccessScrape.Load()),
+		State:                m.State().String(),
+	}
+}
+
+func unixNanoTime(nsec int64) time.Time {
+	if nsec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nsec).UTC()
+}
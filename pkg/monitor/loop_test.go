@@ -0,0 +1,58 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nickeskov/netmon/pkg/clock/clocktest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkMonitor_LoopInfo_Scrape(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2021, 12, 2, 19, 0, 0, 0, time.UTC)
+	fakeClock := clocktest.NewFakeClock(start)
+	scraping := make(chan struct{})
+	release := make(chan struct{})
+	scraperMock := NewMockNodesStatsScrapper(ctrl)
+	gomock.InOrder(
+		scraperMock.EXPECT().ScrapeNodeStats(gomock.Any()).Times(1).DoAndReturn(
+			func(ctx context.Context) (nodesWithStats, error) {
+				close(scraping)
+				<-release
+				return nodesWithStats{{nodeStats: nodeStats{Height: 11, NetByte: MainNetSchemeChar}}}, nil
+			},
+		),
+		scraperMock.EXPECT().ScrapeNodeStats(gomock.Any()).Times(1).Return(nil, errors.New("scrape failed")),
+	)
+
+	mon, err := NewNetworkMonitoring(StateActive, MainNetSchemeChar, 10, scraperMock, 1, NetworkErrorCriteria{},
+		WithClock(fakeClock),
+	)
+	require.NoError(t, err)
+	require.Equal(t, LoopInfo{State: StateActive.String()}, mon.LoopInfo())
+
+	checked := make(chan error)
+	go func() {
+		checked <- mon.CheckNodes(context.Background(), fakeClock.Now())
+	}()
+	<-scraping
+	require.Equal(t, start, mon.LoopInfo().ScrapeStarted)
+	fakeClock.Advance(time.Minute)
+	close(release)
+	require.NoError(t, <-checked)
+
+	info := mon.LoopInfo()
+	require.True(t, info.ScrapeStarted.IsZero())
+	require.Equal(t, start.Add(time.Minute), info.LastSuccessfulScrape)
+
+	// failed scrape doesn't update the latest successful one
+	fakeClock.Advance(time.Minute)
+	require.Error(t, mon.CheckNodes(context.Background(), fakeClock.Now()))
+	require.Equal(t, start.Add(time.Minute), mon.LoopInfo().LastSuccessfulScrape)
+}
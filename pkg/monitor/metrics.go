@@ -0,0 +1,18 @@
+package monitor
+
+import "expvar"
+
+// MetricsPrefix is the common prefix of the netmon metrics names.
+const MetricsPrefix = "netmon_"
+
+// Metrics are published with the expvar package, only metrics with MetricsPrefix are served, see service.DebugVars.
+var (
+	metricScrapeResponseSizeBytes       = expvar.NewInt(MetricsPrefix + "scrape_response_size_bytes")
+	metricScrapeResponseSizeLimitBytes  = expvar.NewInt(MetricsPrefix + "scrape_response_size_limit_bytes")
+	metricScrapeOversizedResponsesTotal = expvar.NewInt(MetricsPrefix + "scrape_oversized_responses_total")
+	metricScrapeNearLimitResponsesTotal = expvar.NewInt(MetricsPrefix + "scrape_near_limit_responses_total")
+
+	metricChainHeight                  = expvar.NewInt(MetricsPrefix + "chain_height")
+	metricChainAvgBlockIntervalSeconds = expvar.NewMap(MetricsPrefix + "chain_avg_block_interval_seconds") // by window
+	metricChainBlocksPerMinute         = expvar.NewMap(MetricsPrefix + "chain_blocks_per_minute")          // by window
+)
@@ -2,10 +2,15 @@ package monitor
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/nickeskov/netmon/pkg/clock"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
@@ -63,16 +68,37 @@ func (s NetworkMonitoringState) String() string {
 }
 
 type NetworkStatusInfo struct {
+	Updated  time.Time         `json:"updated,omitempty"`
+	Network  NetworkSchemeChar `json:"network"`
+	Status   bool              `json:"status"` // legacy status, it's false if the network error streak has been reached
+	Height   int               `json:"height"`
+	Severity Severity          `json:"severity"`
+	Reasons  []SeverityReason  `json:"reasons"`
+}
+
+type NetworkNodesInfo struct {
 	Updated time.Time         `json:"updated,omitempty"`
 	Network NetworkSchemeChar `json:"network"`
-	Status  bool              `json:"status"`
-	Height  int               `json:"height"`
+	Nodes   []NodeInfo        `json:"nodes"`
 }
 
 type Monitor interface {
-	CheckNodes(now time.Time) error
+	CheckNodes(ctx context.Context, now time.Time) error
 	NetworkStatusInfo() NetworkStatusInfo
+	NetworkNodesInfo() NetworkNodesInfo
+	NetworkForksInfo() NetworkForksInfo
+	NetworkFlappingInfo() NetworkFlappingInfo
+	NetworkChainInfo() NetworkChainInfo
+	NetworkBaselinesInfo() NetworkBaselinesInfo
+	NetworkNodesScoresInfo() NetworkNodesScoresInfo
+	NetworkSLA(from, to time.Time) (SLAReport, error)
+	NetworkHistoryInfo() NetworkHistoryInfo
+	NetworkIncidentsInfo() NetworkIncidentsInfo
+	NetworkIncident(id int) (Incident, error)
+	AddIncidentNote(id int, author, text string) (Incident, error)
 	NetworkOperatesStable() bool
+	LoopInfo() LoopInfo
+	WatchStatus() (updates <-chan NetworkStatusInfo, cancel func())
 	State() NetworkMonitoringState
 	ChangeState(state NetworkMonitoringState) (previous NetworkMonitoringState)
 }
@@ -80,19 +106,121 @@ type Monitor interface {
 type NetworkMonitor struct {
 	mu sync.RWMutex
 
-	netSchemeChar NetworkSchemeChar
-	scrapper      NodesStatsScrapper
+	netSchemeChar    NetworkSchemeChar
+	scrapper         NodesStatsScrapper
+	clock            clock.Clock
+	nodesFilter      NodesFilter
+	blockRateWindows []time.Duration
+	transitions      *TransitionStore
+	severityRules    SeverityRules
+	statsRecorder    *StatsRecorder
+	lastSeenFile     string
 
 	// state fields
 	monitorState       NetworkMonitoringState
 	statsHistory       statsHistoryDeque
 	networkErrorStreak int
+	anomalies          anomalyDetector
+	scorer             nodeScorer
+	incidents          incidentsLog
+	watchers           statusWatchers
+	criteriaSince      map[string]time.Time // when currently alerted criteria have been alerted first
+	stateChangedAt     time.Time
+
+	// loop fields are unix nanoseconds which are updated without the lock, see LoopInfo
+	loopStarted       atomic.Int64
+	loopIteration     atomic.Int64
+	loopPollInterval  atomic.Int64
+	scrapeStarted     atomic.Int64
+	lastSuccessScrape atomic.Int64
 
 	// criteria fields
 	alertOnNetworkErrorStreak int
 	criteria                  NetworkErrorCriteria
 }
 
+type networkMonitorOptions struct {
+	clock            clock.Clock
+	nodesFilter      NodesFilter
+	blockRateWindows []time.Duration
+	transitions      *TransitionStore
+	scoresRecords    io.Reader
+	severityRules    SeverityRules
+	statsRecorder    *StatsRecorder
+	lastSeenFile     string
+	maxIncidents     int
+}
+
+type NetworkMonitorOption func(o *networkMonitorOptions)
+
+// WithClock sets the clock which is used by the monitor loop. Real clock is used by default.
+func WithClock(c clock.Clock) NetworkMonitorOption {
+	return func(o *networkMonitorOptions) {
+		o.clock = c
+	}
+}
+
+// WithNodesFilter sets the filter which is applied to the network nodes before criteria calculation.
+func WithNodesFilter(filter NodesFilter) NetworkMonitorOption {
+	return func(o *networkMonitorOptions) {
+		o.nodesFilter = filter
+	}
+}
+
+// WithBlockRateWindows sets the windows over which block rates are reported, see NetworkChainInfo.
+func WithBlockRateWindows(windows ...time.Duration) NetworkMonitorOption {
+	return func(o *networkMonitorOptions) {
+		o.blockRateWindows = windows
+	}
+}
+
+// WithTransitionStore sets the store of network status transitions which are used for SLA reporting.
+// By default, transitions are kept only in memory.
+func WithTransitionStore(store *TransitionStore) NetworkMonitorOption {
+	return func(o *networkMonitorOptions) {
+		o.transitions = store
+	}
+}
+
+// WithLastSeenFile sets the file in which the time of the latest monitor loop iteration is kept. If netmon has
+// crashed, the unknown status transition is added at this time on start, so the time till the restart is reported as
+// unknown instead of the latest recorded status.
+func WithLastSeenFile(path string) NetworkMonitorOption {
+	return func(o *networkMonitorOptions) {
+		o.lastSeenFile = path
+	}
+}
+
+// WithMaxIncidents sets the number of the latest incidents which are kept, DefaultMaxIncidents is used by default.
+func WithMaxIncidents(n int) NetworkMonitorOption {
+	return func(o *networkMonitorOptions) {
+		o.maxIncidents = n
+	}
+}
+
+// WithNodesScoresRecords sets the reader of previously recorded stats which are replayed into the node scores on
+// start, see StatsRecorder and ScanStatsRecords. Records must be ordered by time.
+func WithNodesScoresRecords(records io.Reader) NetworkMonitorOption {
+	return func(o *networkMonitorOptions) {
+		o.scoresRecords = records
+	}
+}
+
+// WithStatsRecorder sets the recorder to which every successfully scraped stats payload is written
+// together with the check time. Raw payload is recorded if the scrapper provides it.
+func WithStatsRecorder(recorder *StatsRecorder) NetworkMonitorOption {
+	return func(o *networkMonitorOptions) {
+		o.statsRecorder = recorder
+	}
+}
+
+// WithSeverityRules sets severity rules of criteria, they override DefaultSeverityRules of the same criteria.
+func WithSeverityRules(rules SeverityRules) NetworkMonitorOption {
+	return func(o *networkMonitorOptions) {
+		o.severityRules = rules
+	}
+}
+
 func NewNetworkMonitoring(
 	initialMonitorState NetworkMonitoringState,
 	netSchemeChar NetworkSchemeChar,
@@ -100,6 +228,7 @@ func NewNetworkMonitoring(
 	nodesStatsScraper NodesStatsScrapper,
 	alertOnNetworkErrorStreak int,
 	criteria NetworkErrorCriteria,
+	opts ...NetworkMonitorOption,
 ) (NetworkMonitor, error) {
 	if maxStatsHistoryLen < 1 {
 		return NetworkMonitor{}, errors.New("maxStatsHistoryLen should be greater than zero")
@@ -107,71 +236,371 @@ func NewNetworkMonitoring(
 	if alertOnNetworkErrorStreak < 1 {
 		return NetworkMonitor{}, errors.New("alertOnNetworkErrorStreak should be greater than zero")
 	}
-	switch netSchemeChar {
-	case MainNetSchemeChar, TestNetSchemeChar, StageNetSchemeChar, CustomNetSchemeChar:
-		// ok
-	default:
+	if !KnownNetworkSchemeChar(netSchemeChar) {
 		return NetworkMonitor{}, errors.Errorf("invalid network scheme byte %q", netSchemeChar)
 	}
+	options := networkMonitorOptions{
+		clock:            clock.Real(),
+		blockRateWindows: DefaultBlockRateWindows,
+		maxIncidents:     DefaultMaxIncidents,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.maxIncidents < 1 {
+		return NetworkMonitor{}, errors.New("maxIncidents should be greater than zero")
+	}
+	if options.transitions == nil {
+		options.transitions = NewTransitionStore(nil, nil)
+	}
+	if err := options.nodesFilter.Validate(); err != nil {
+		return NetworkMonitor{}, err
+	}
+	if options.lastSeenFile != "" {
+		if err := recordCrashedInterval(options.transitions, options.lastSeenFile); err != nil {
+			return NetworkMonitor{}, err
+		}
+	}
+	for _, domain := range criteria.RequiredNodes.Nodes {
+		// otherwise the required node is always missing
+		if !options.nodesFilter.Match(domain) {
+			return NetworkMonitor{}, errors.Errorf("required node %q is excluded by the nodes filter", domain)
+		}
+	}
+	if err := options.severityRules.Validate(); err != nil {
+		return NetworkMonitor{}, err
+	}
+	severityRules := DefaultSeverityRules()
+	for criterion, rule := range options.severityRules {
+		severityRules[criterion] = rule
+	}
+	for _, window := range options.blockRateWindows {
+		if window <= 0 {
+			return NetworkMonitor{}, errors.Errorf("invalid block rate window %s", window)
+		}
+	}
+	scorer := newNodeScorer(criteria)
+	if options.scoresRecords != nil {
+		n, err := ScanStatsRecords(options.scoresRecords, func(record StatsRecord) error {
+			nodes := options.nodesFilter.Apply(record.Nodes.NodesWithNetworkSchemeChar(netSchemeChar))
+			scorer.observe(record.Timestamp, nodes, criteria.NodeWeights)
+			return nil
+		})
+		if err != nil {
+			return NetworkMonitor{}, errors.Wrap(err, "failed to replay stats records into node scores")
+		}
+		zap.S().Infof("%d stats records have been replayed into node scores", n)
+	}
 	return NetworkMonitor{
 		monitorState:              initialMonitorState,
 		netSchemeChar:             netSchemeChar,
 		scrapper:                  nodesStatsScraper,
+		clock:                     options.clock,
+		nodesFilter:               options.nodesFilter,
+		blockRateWindows:          options.blockRateWindows,
+		transitions:               options.transitions,
+		severityRules:             severityRules,
+		statsRecorder:             options.statsRecorder,
+		lastSeenFile:              options.lastSeenFile,
+		criteriaSince:             make(map[string]time.Time),
 		statsHistory:              newStatsDeque(maxStatsHistoryLen),
+		anomalies:                 newAnomalyDetector(criteria.Anomaly),
+		scorer:                    scorer,
+		incidents:                 newIncidentsLog(options.maxIncidents),
 		alertOnNetworkErrorStreak: alertOnNetworkErrorStreak,
 		criteria:                  criteria,
 	}, nil
 }
 
-func (m *NetworkMonitor) CheckNodes(now time.Time) error {
+// CheckNodes scrapes nodes stats and updates the network status. Status watchers are notified if the check succeeds.
+func (m *NetworkMonitor) CheckNodes(ctx context.Context, now time.Time) error {
+	if err := m.checkNodes(ctx, now); err != nil {
+		return err
+	}
+	m.watchers.notify(m.NetworkStatusInfo())
+	return nil
+}
+
+// scrapeNodeStats scrapes nodes stats and returns the payload for the stats recorder if it's set.
+func (m *NetworkMonitor) scrapeNodeStats(ctx context.Context) (nodesWithStats, json.RawMessage, error) {
+	if m.statsRecorder == nil {
+		nodes, err := m.scrapper.ScrapeNodeStats(ctx)
+		return nodes, nil, err
+	}
+	if raw, ok := m.scrapper.(rawNodesStatsScrapper); ok {
+		return raw.ScrapeRawNodeStats(ctx)
+	}
+	nodes, err := m.scrapper.ScrapeNodeStats(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	payload, err := json.Marshal(nodes)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to marshal scraped nodes stats")
+	}
+	return nodes, payload, nil
+}
+
+func (m *NetworkMonitor) checkNodes(ctx context.Context, now time.Time) error {
 	if state := m.State(); state != StateActive {
-		zap.S().Debugf("monitor is frozen, current state is %q", state)
+		zap.S().Debugw("monitor is frozen", "network", string(m.netSchemeChar), "state", state.String())
+		m.recordStatus(now)
 		return nil
 	}
 
-	allNetworksNodes, err := m.scrapper.ScrapeNodeStats()
+	m.scrapeStarted.Store(m.clock.Now().UnixNano())
+	allNetworksNodes, payload, err := m.scrapeNodeStats(ctx)
+	m.scrapeStarted.Store(0)
 	if err != nil {
 		return err
 	}
+	m.lastSuccessScrape.Store(m.clock.Now().UnixNano())
+	if m.statsRecorder != nil {
+		if err := m.statsRecorder.Record(now.UTC(), payload); err != nil {
+			zap.S().Errorf("failed to record scraped nodes stats: %v", err)
+		}
+	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if state := m.monitorState; state != StateActive {
-		zap.S().Debugf("monitor is frozen, current state is %q", state)
+		zap.S().Debugw("monitor is frozen", "network", string(m.netSchemeChar), "state", state.String())
+		m.unsafeRecordStatus(now)
 		return nil
 	}
 
-	currentNetworkNodes := allNetworksNodes.NodesWithNetworkSchemeChar(m.netSchemeChar)
+	if unknown := allNetworksNodes.UnknownNetworkNodes(); len(unknown) != 0 {
+		zap.S().Warnf("%d nodes with unknown netbyte have been detected: %v", len(unknown), unknown.Domains())
+	}
+	currentNetworkNodes := m.nodesFilter.Apply(allNetworksNodes.NodesWithNetworkSchemeChar(m.netSchemeChar))
+	if malformed := currentNetworkNodes.MalformedNodes(); len(malformed) != 0 {
+		zap.S().Warnf("%d malformed nodes of network %q have been detected: %v",
+			len(malformed), m.netSchemeChar, malformed.Domains(),
+		)
+	}
 	calc, err := newNetstatCalculator(m.criteria, currentNetworkNodes)
 	if err != nil {
 		return err
 	}
 
+	m.scorer.observe(now, currentNetworkNodes, m.criteria.NodeWeights)
+
 	newStatsSnapshot := &statsDataSnapshot{
 		snapshotCreationTime: now,
 		nodes:                currentNetworkNodes,
 		maxHeight:            calc.CurrentMaxHeight(),
 		nodesDownCriterion:   calc.AlertDownNodesCriterion(),
+		syncingCriterion:     calc.AlertSyncingNodesCriterion(),
 		heightCriterion:      calc.AlertHeightCriterion(),
 		stateHashCriterion:   calc.AlertStateHashCriterion(),
 	}
+	if m.anomalies.observe(now, &calc) {
+		zap.S().Debugf("anomaly of network %q indicators has been detected: %+v", m.netSchemeChar, m.anomalies.indicators())
+		newStatsSnapshot.anomalyCriterion = true
+	}
+	if lagging := calc.LaggingNodes(); calc.alertHeightLagCriterion(lagging) {
+		zap.S().Debugf("lagging nodes of network %q behind height %d: %v", m.netSchemeChar, newStatsSnapshot.maxHeight, lagging)
+		newStatsSnapshot.heightLagCriterion = true
+	}
+	if failed := calc.FailedRequiredNodes(); len(failed) != 0 {
+		zap.S().Debugf("required nodes of network %q are down, missing or lagging: %v", m.netSchemeChar, failed)
+		newStatsSnapshot.requiredNodesCriterion = true
+	}
 	outdatedStats := m.statsHistory.PushFront(newStatsSnapshot)
+	if m.criteria.NodesFlapping.Enabled() {
+		flapping := m.statsHistory.nodesTransitions(m.criteria.NodesFlapping)
+		if cnt := countFlapping(flapping); cnt > m.criteria.NodesFlapping.MaxFlappingNodes {
+			zap.S().Debugf("%d flapping nodes of network %q have been detected: %v", cnt, m.netSchemeChar, flapping)
+			newStatsSnapshot.flappingCriterion = true
+		}
+	}
+	if m.statsHistory.alertBlockIntervalCriterion(m.criteria.BlockInterval) {
+		zap.S().Debugf("block interval of network %q deviates from expected %s", m.netSchemeChar, m.criteria.BlockInterval.Expected)
+		newStatsSnapshot.blockIntervalCriterion = true
+	}
+	m.unsafeUpdateCriteriaSince(now, newStatsSnapshot.alertedCriteria())
+	updateChainMetrics(newStatsSnapshot.maxHeight, m.statsHistory.blockRates(m.blockRateWindows))
 	zap.S().Debugf("FRESH stats has been pushed to stats history storage, stats=%q", newStatsSnapshot)
 	zap.S().Debugf("OUTDATED stats has been dropped from stats history storage, stats=%q", outdatedStats)
 
-	if newStatsSnapshot.nodesDownCriterion || newStatsSnapshot.heightCriterion || newStatsSnapshot.stateHashCriterion {
-		zap.S().Debugf("network %q error has been detected, increasing networkErrorStreak counter", m.netSchemeChar)
+	if newStatsSnapshot.anyCriterionAlerted() {
 		// increment error streak counter
 		m.networkErrorStreak++
+		zap.S().Debugw("network error has been detected",
+			"network", string(m.netSchemeChar),
+			"criteria", newStatsSnapshot.alertedCriteria(),
+			"error_streak", m.networkErrorStreak,
+		)
 	} else {
 		// all ok - reset streak
-		zap.S().Debugf("network %q operates normally and alert hasn't been generated", m.netSchemeChar)
+		zap.S().Debugw("network operates normally and alert hasn't been generated", "network", string(m.netSchemeChar))
 		m.networkErrorStreak = 0
 	}
+	m.unsafeTrackIncident(now)
+	m.unsafeRecordStatus(now)
 	return nil
 }
 
+// unsafeTrackIncident opens the incident if the network has become degraded and closes it if the network has
+// recovered. Alerted criteria of the latest snapshot are added to the ongoing incident if the monitor is active.
+func (m *NetworkMonitor) unsafeTrackIncident(now time.Time) {
+	stable := m.unsafeNetworkOperatesStable()
+	ongoing := m.incidents.ongoing() != nil
+	switch {
+	case stable && ongoing:
+		m.incidents.close(now)
+		zap.S().Debugw("network incident has been closed", "network", string(m.netSchemeChar))
+	case !stable && !ongoing:
+		incident := m.incidents.open(now)
+		zap.S().Debugw("network incident has been opened", "network", string(m.netSchemeChar), "incident_id", incident.ID)
+		// snapshots of the error streak which has opened the incident
+		for i := min(m.networkErrorStreak, m.statsHistory.Len()) - 1; i >= 0; i-- {
+			m.unsafeObserveIncident(m.statsHistory.At(i))
+		}
+	case !stable && m.monitorState == StateActive && m.statsHistory.Len() != 0:
+		front := m.statsHistory.Front()
+		if criteria := front.alertedCriteria(); len(criteria) != 0 {
+			severity, _ := m.unsafeSeverity()
+			m.incidents.observe(severity, criteria, involvedNodes(m.criteria, front.nodes))
+		}
+	}
+}
+
+// unsafeObserveIncident adds the snapshot to the ongoing incident, criteria severity isn't escalated here.
+func (m *NetworkMonitor) unsafeObserveIncident(snapshot *statsDataSnapshot) {
+	if criteria := snapshot.alertedCriteria(); len(criteria) != 0 {
+		m.incidents.observe(m.severityRules.criteriaSeverity(criteria), criteria, involvedNodes(m.criteria, snapshot.nodes))
+	}
+}
+
+func (m *NetworkMonitor) unsafeUpdateCriteriaSince(now time.Time, alerted []string) {
+	current := make(map[string]time.Time, len(alerted))
+	for _, criterion := range alerted {
+		since, ok := m.criteriaSince[criterion]
+		if !ok {
+			since = now
+		}
+		current[criterion] = since
+	}
+	m.criteriaSince = current
+}
+
+// unsafeSeverity returns the network severity and its reasons sorted by severity in descending order.
+// Severity of the frozen monitor is defined by its state.
+func (m *NetworkMonitor) unsafeSeverity() (Severity, []SeverityReason) {
+	reasons := []SeverityReason{}
+	switch m.monitorState {
+	case StateFrozenNetworkOperatesStable:
+		return SeverityOK, reasons
+	case StateFrozenNetworkDegraded:
+		reason := SeverityReason{Reason: m.monitorState.String(), Severity: SeverityDegraded, Since: m.stateChangedAt}
+		return SeverityDegraded, append(reasons, reason)
+	}
+	if m.statsHistory.Len() == 0 {
+		return SeverityOK, reasons
+	}
+	latest := m.statsHistory.Front().snapshotCreationTime
+	// severity is debounced by the error streak as well as the legacy status
+	maxSeverity := SeverityCritical
+	if m.unsafeNetworkOperatesStable() {
+		maxSeverity = SeverityWarning
+	}
+	severity := SeverityOK
+	for criterion, since := range m.criteriaSince {
+		reason := SeverityReason{
+			Reason:   criterion,
+			Severity: min(m.severityRules.severity(criterion, latest.Sub(since)), maxSeverity),
+			Since:    since,
+		}
+		severity = max(severity, reason.Severity)
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if reasons[i].Severity != reasons[j].Severity {
+			return reasons[i].Severity > reasons[j].Severity
+		}
+		return reasons[i].Reason < reasons[j].Reason
+	})
+	return severity, reasons
+}
+
+func (m *NetworkMonitor) unsafeNetworkStatus() NetworkStatus {
+	switch {
+	case m.monitorState != StateActive:
+		return NetworkStatusFrozen
+	case m.unsafeNetworkOperatesStable():
+		return NetworkStatusOperational
+	default:
+		return NetworkStatusDegraded
+	}
+}
+
+// recordCrashedInterval appends the unknown status transition at the last seen time if netmon hasn't recorded it
+// on stop, i.e. it has crashed.
+func recordCrashedInterval(transitions *TransitionStore, lastSeenFile string) error {
+	lastSeen, err := readLastSeen(lastSeenFile)
+	if err != nil {
+		return err
+	}
+	last, ok := transitions.Last()
+	if !ok || last.Status == NetworkStatusUnknown || !lastSeen.After(last.Timestamp) {
+		return nil
+	}
+	if err := transitions.Append(StatusTransition{Timestamp: lastSeen, Status: NetworkStatusUnknown}); err != nil {
+		return errors.Wrap(err, "failed to record status transition of the crashed monitor")
+	}
+	zap.S().Warnf("monitor hasn't been stopped gracefully, network status is unknown since %s", lastSeen)
+	return nil
+}
+
+// recordUnknownStatus appends the unknown status transition, so the time when the network isn't monitored
+// is excluded from the uptime calculation.
+func (m *NetworkMonitor) recordUnknownStatus(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	last, ok := m.transitions.Last()
+	if !ok || last.Status == NetworkStatusUnknown {
+		return
+	}
+	if err := m.transitions.Append(StatusTransition{Timestamp: now, Status: NetworkStatusUnknown}); err != nil {
+		zap.S().Errorw("failed to record network status transition",
+			"network", string(m.netSchemeChar), "to", string(NetworkStatusUnknown), zap.Error(err),
+		)
+		return
+	}
+	zap.S().Infow("network status has changed",
+		"network", string(m.netSchemeChar), "from", string(last.Status), "to", string(NetworkStatusUnknown),
+	)
+}
+
+func (m *NetworkMonitor) recordStatus(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unsafeRecordStatus(now)
+}
+
+// unsafeRecordStatus appends the status transition if the network status has changed since the latest transition.
+func (m *NetworkMonitor) unsafeRecordStatus(now time.Time) {
+	status := m.unsafeNetworkStatus()
+	last, ok := m.transitions.Last()
+	if ok && last.Status == status {
+		return
+	}
+	if !ok {
+		last.Status = NetworkStatusUnknown // status is unknown before the first transition
+	}
+	if err := m.transitions.Append(StatusTransition{Timestamp: now, Status: status}); err != nil {
+		zap.S().Errorw("failed to record network status transition",
+			"network", string(m.netSchemeChar), "to", string(status), zap.Error(err),
+		)
+		return
+	}
+	zap.S().Infow("network status has changed",
+		"network", string(m.netSchemeChar), "from", string(last.Status), "to", string(status),
+	)
+}
+
 func (m *NetworkMonitor) NetworkStatusInfo() NetworkStatusInfo {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -181,6 +610,7 @@ func (m *NetworkMonitor) NetworkStatusInfo() NetworkStatusInfo {
 		Network: m.netSchemeChar,
 		Height:  -1,
 	}
+	statusInfo.Severity, statusInfo.Reasons = m.unsafeSeverity()
 	if m.statsHistory.Len() != 0 {
 		front := m.statsHistory.Front()
 
@@ -190,6 +620,161 @@ func (m *NetworkMonitor) NetworkStatusInfo() NetworkStatusInfo {
 	return statusInfo
 }
 
+func (m *NetworkMonitor) NetworkNodesInfo() NetworkNodesInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	nodesInfo := NetworkNodesInfo{
+		Network: m.netSchemeChar,
+		Nodes:   []NodeInfo{},
+	}
+	if m.statsHistory.Len() != 0 {
+		front := m.statsHistory.Front()
+
+		nodesInfo.Updated = front.snapshotCreationTime
+		nodesInfo.Nodes = front.nodes.NodesInfo()
+		for i := range nodesInfo.Nodes {
+			node := &nodesInfo.Nodes[i]
+			if node.Height <= 0 {
+				continue // lag is defined only for working nodes
+			}
+			node.Lag = front.maxHeight - node.Height
+			node.Lagging = m.criteria.NodesLag.Enabled() && node.Lag > m.criteria.NodesLag.MaxLag
+		}
+	}
+	return nodesInfo
+}
+
+func (m *NetworkMonitor) NetworkForksInfo() NetworkForksInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	forksInfo := NetworkForksInfo{
+		Network: m.netSchemeChar,
+		Forks:   []Fork{},
+	}
+	if m.statsHistory.Len() != 0 {
+		front := m.statsHistory.Front()
+
+		forksInfo.Updated = front.snapshotCreationTime
+		forksInfo.StateHashCriterion = front.stateHashCriterion
+		if forks := m.unsafeForks(); forks != nil {
+			forksInfo.Forks = forks
+		}
+	}
+	return forksInfo
+}
+
+func (m *NetworkMonitor) NetworkFlappingInfo() NetworkFlappingInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	flappingInfo := NetworkFlappingInfo{
+		Network:   m.netSchemeChar,
+		Snapshots: m.statsHistory.Len(),
+		Nodes:     []FlappingNode{},
+	}
+	if m.statsHistory.Len() != 0 {
+		flappingInfo.Updated = m.statsHistory.Front().snapshotCreationTime
+		flappingInfo.From = m.statsHistory.Back().snapshotCreationTime
+		if nodes := m.statsHistory.nodesTransitions(m.criteria.NodesFlapping); nodes != nil {
+			flappingInfo.Nodes = nodes
+		}
+	}
+	return flappingInfo
+}
+
+func (m *NetworkMonitor) NetworkChainInfo() NetworkChainInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	chainInfo := NetworkChainInfo{
+		Network:               m.netSchemeChar,
+		Height:                -1,
+		ExpectedBlockInterval: DefaultExpectedBlockInterval,
+		Rates:                 m.statsHistory.blockRates(m.blockRateWindows),
+	}
+	if m.criteria.BlockInterval.Enabled() {
+		chainInfo.ExpectedBlockInterval = m.criteria.BlockInterval.Expected
+	}
+	if m.statsHistory.Len() != 0 {
+		front := m.statsHistory.Front()
+
+		chainInfo.Updated = front.snapshotCreationTime
+		chainInfo.Height = front.maxHeight
+		chainInfo.BlockIntervalAlert = front.blockIntervalCriterion
+	}
+	return chainInfo
+}
+
+func (m *NetworkMonitor) NetworkBaselinesInfo() NetworkBaselinesInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return NetworkBaselinesInfo{
+		Updated:    m.anomalies.updated,
+		Network:    m.netSchemeChar,
+		Enabled:    m.criteria.Anomaly.Enabled(),
+		Samples:    m.anomalies.samples,
+		Indicators: m.anomalies.indicators(),
+	}
+}
+
+func (m *NetworkMonitor) NetworkNodesScoresInfo() NetworkNodesScoresInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return NetworkNodesScoresInfo{
+		Updated: m.scorer.updated,
+		Network: m.netSchemeChar,
+		From:    m.scorer.from,
+		Samples: m.scorer.samples,
+		MaxLag:  m.scorer.maxLag,
+		Nodes:   m.scorer.scores(),
+	}
+}
+
+func (m *NetworkMonitor) NetworkIncidentsInfo() NetworkIncidentsInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return NetworkIncidentsInfo{
+		Network:   m.netSchemeChar,
+		Incidents: m.incidents.list(m.clock.Now().UTC()),
+	}
+}
+
+func (m *NetworkMonitor) NetworkIncident(id int) (Incident, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	incident, ok := m.incidents.get(id, m.clock.Now().UTC())
+	if !ok {
+		return Incident{}, ErrIncidentNotFound
+	}
+	return incident, nil
+}
+
+func (m *NetworkMonitor) AddIncidentNote(id int, author, text string) (Incident, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.incidents.addNote(id, IncidentNote{Timestamp: m.clock.Now().UTC(), Author: author, Text: text})
+}
+
+// NetworkSLA calculates SLA report over [from, to) period, the period end is limited by the current time.
+func (m *NetworkMonitor) NetworkSLA(from, to time.Time) (SLAReport, error) {
+	if now := m.clock.Now(); to.After(now) {
+		to = now
+	}
+	report, err := ComputeSLA(m.transitions.Transitions(from, to), from, to)
+	if err != nil {
+		return SLAReport{}, err
+	}
+	report.Network = m.netSchemeChar
+	return report, nil
+}
+
 func (m *NetworkMonitor) NetworkOperatesStable() bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -225,22 +810,44 @@ func (m *NetworkMonitor) ChangeState(state NetworkMonitoringState) (previous Net
 		return previous // state the same - do nothing
 	}
 
-	zap.S().Debugf("changing monitor state from %q to %q", previous, state)
+	zap.S().Debugw("changing monitor state",
+		"network", string(m.netSchemeChar), "from", previous.String(), "to", state.String(),
+	)
+	now := m.clock.Now().UTC()
 	m.monitorState = state
-	// we have to reset the streak in case of state changing
+	m.stateChangedAt = now
+	// we have to reset the streak and alerted criteria in case of state changing
 	m.networkErrorStreak = 0
+	m.criteriaSince = make(map[string]time.Time)
+	m.unsafeTrackIncident(now)
+	m.incidents.stateChanged(now, previous, state)
+	m.unsafeRecordStatus(now)
 	return previous
 }
 
+// Run checks nodes every poll interval until the context is done. The network status is unknown since the start
+// till the first successful check and after the stop, so the time when netmon isn't running is reported as unknown.
 func (m *NetworkMonitor) Run(ctx context.Context, pollNodesStatsInterval time.Duration) {
+	m.loopPollInterval.Store(int64(pollNodesStatsInterval))
+	m.loopStarted.Store(m.clock.Now().UnixNano())
+	m.recordUnknownStatus(m.clock.Now().UTC())
+	defer func() {
+		m.recordUnknownStatus(m.clock.Now().UTC())
+	}()
 	for {
-		if err := m.CheckNodes(time.Now().UTC()); err != nil {
+		m.loopIteration.Store(m.clock.Now().UnixNano())
+		if err := m.CheckNodes(ctx, m.clock.Now().UTC()); err != nil {
 			zap.S().Errorf("failed to check nodes status: %v", err)
 		}
+		if m.lastSeenFile != "" {
+			if err := writeLastSeen(m.lastSeenFile, m.clock.Now().UTC()); err != nil {
+				zap.S().Errorf("failed to store last seen time: %v", err)
+			}
+		}
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(pollNodesStatsInterval):
+		case <-m.clock.After(pollNodesStatsInterval):
 			continue
 		}
 	}
@@ -5,6 +5,7 @@
 package monitor
 
 import (
+	context "context"
 	reflect "reflect"
 	time "time"
 
@@ -34,6 +35,21 @@ func (m *MockMonitor) EXPECT() *MockMonitorMockRecorder {
 	return m.recorder
 }
 
+// AddIncidentNote mocks base method.
+func (m *MockMonitor) AddIncidentNote(id int, author, text string) (Incident, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddIncidentNote", id, author, text)
+	ret0, _ := ret[0].(Incident)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddIncidentNote indicates an expected call of AddIncidentNote.
+func (mr *MockMonitorMockRecorder) AddIncidentNote(id, author, text interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddIncidentNote", reflect.TypeOf((*MockMonitor)(nil).AddIncidentNote), id, author, text)
+}
+
 // ChangeState mocks base method.
 func (m *MockMonitor) ChangeState(state NetworkMonitoringState) NetworkMonitoringState {
 	m.ctrl.T.Helper()
@@ -49,17 +65,158 @@ func (mr *MockMonitorMockRecorder) ChangeState(state interface{}) *gomock.Call {
 }
 
 // CheckNodes mocks base method.
-func (m *MockMonitor) CheckNodes(now time.Time) error {
+func (m *MockMonitor) CheckNodes(ctx context.Context, now time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CheckNodes", now)
+	ret := m.ctrl.Call(m, "CheckNodes", ctx, now)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CheckNodes indicates an expected call of CheckNodes.
-func (mr *MockMonitorMockRecorder) CheckNodes(now interface{}) *gomock.Call {
+func (mr *MockMonitorMockRecorder) CheckNodes(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckNodes", reflect.TypeOf((*MockMonitor)(nil).CheckNodes), ctx, now)
+}
+
+// LoopInfo mocks base method.
+func (m *MockMonitor) LoopInfo() LoopInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoopInfo")
+	ret0, _ := ret[0].(LoopInfo)
+	return ret0
+}
+
+// LoopInfo indicates an expected call of LoopInfo.
+func (mr *MockMonitorMockRecorder) LoopInfo() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoopInfo", reflect.TypeOf((*MockMonitor)(nil).LoopInfo))
+}
+
+// NetworkBaselinesInfo mocks base method.
+func (m *MockMonitor) NetworkBaselinesInfo() NetworkBaselinesInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkBaselinesInfo")
+	ret0, _ := ret[0].(NetworkBaselinesInfo)
+	return ret0
+}
+
+// NetworkBaselinesInfo indicates an expected call of NetworkBaselinesInfo.
+func (mr *MockMonitorMockRecorder) NetworkBaselinesInfo() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkBaselinesInfo", reflect.TypeOf((*MockMonitor)(nil).NetworkBaselinesInfo))
+}
+
+// NetworkChainInfo mocks base method.
+func (m *MockMonitor) NetworkChainInfo() NetworkChainInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkChainInfo")
+	ret0, _ := ret[0].(NetworkChainInfo)
+	return ret0
+}
+
+// NetworkChainInfo indicates an expected call of NetworkChainInfo.
+func (mr *MockMonitorMockRecorder) NetworkChainInfo() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkChainInfo", reflect.TypeOf((*MockMonitor)(nil).NetworkChainInfo))
+}
+
+// NetworkFlappingInfo mocks base method.
+func (m *MockMonitor) NetworkFlappingInfo() NetworkFlappingInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkFlappingInfo")
+	ret0, _ := ret[0].(NetworkFlappingInfo)
+	return ret0
+}
+
+// NetworkFlappingInfo indicates an expected call of NetworkFlappingInfo.
+func (mr *MockMonitorMockRecorder) NetworkFlappingInfo() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckNodes", reflect.TypeOf((*MockMonitor)(nil).CheckNodes), now)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkFlappingInfo", reflect.TypeOf((*MockMonitor)(nil).NetworkFlappingInfo))
+}
+
+// NetworkForksInfo mocks base method.
+func (m *MockMonitor) NetworkForksInfo() NetworkForksInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkForksInfo")
+	ret0, _ := ret[0].(NetworkForksInfo)
+	return ret0
+}
+
+// NetworkForksInfo indicates an expected call of NetworkForksInfo.
+func (mr *MockMonitorMockRecorder) NetworkForksInfo() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkForksInfo", reflect.TypeOf((*MockMonitor)(nil).NetworkForksInfo))
+}
+
+// NetworkHistoryInfo mocks base method.
+func (m *MockMonitor) NetworkHistoryInfo() NetworkHistoryInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkHistoryInfo")
+	ret0, _ := ret[0].(NetworkHistoryInfo)
+	return ret0
+}
+
+// NetworkHistoryInfo indicates an expected call of NetworkHistoryInfo.
+func (mr *MockMonitorMockRecorder) NetworkHistoryInfo() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkHistoryInfo", reflect.TypeOf((*MockMonitor)(nil).NetworkHistoryInfo))
+}
+
+// NetworkIncident mocks base method.
+func (m *MockMonitor) NetworkIncident(id int) (Incident, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkIncident", id)
+	ret0, _ := ret[0].(Incident)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetworkIncident indicates an expected call of NetworkIncident.
+func (mr *MockMonitorMockRecorder) NetworkIncident(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkIncident", reflect.TypeOf((*MockMonitor)(nil).NetworkIncident), id)
+}
+
+// NetworkIncidentsInfo mocks base method.
+func (m *MockMonitor) NetworkIncidentsInfo() NetworkIncidentsInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkIncidentsInfo")
+	ret0, _ := ret[0].(NetworkIncidentsInfo)
+	return ret0
+}
+
+// NetworkIncidentsInfo indicates an expected call of NetworkIncidentsInfo.
+func (mr *MockMonitorMockRecorder) NetworkIncidentsInfo() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkIncidentsInfo", reflect.TypeOf((*MockMonitor)(nil).NetworkIncidentsInfo))
+}
+
+// NetworkNodesInfo mocks base method.
+func (m *MockMonitor) NetworkNodesInfo() NetworkNodesInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkNodesInfo")
+	ret0, _ := ret[0].(NetworkNodesInfo)
+	return ret0
+}
+
+// NetworkNodesInfo indicates an expected call of NetworkNodesInfo.
+func (mr *MockMonitorMockRecorder) NetworkNodesInfo() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkNodesInfo", reflect.TypeOf((*MockMonitor)(nil).NetworkNodesInfo))
+}
+
+// NetworkNodesScoresInfo mocks base method.
+func (m *MockMonitor) NetworkNodesScoresInfo() NetworkNodesScoresInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkNodesScoresInfo")
+	ret0, _ := ret[0].(NetworkNodesScoresInfo)
+	return ret0
+}
+
+// NetworkNodesScoresInfo indicates an expected call of NetworkNodesScoresInfo.
+func (mr *MockMonitorMockRecorder) NetworkNodesScoresInfo() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkNodesScoresInfo", reflect.TypeOf((*MockMonitor)(nil).NetworkNodesScoresInfo))
 }
 
 // NetworkOperatesStable mocks base method.
@@ -76,6 +233,21 @@ func (mr *MockMonitorMockRecorder) NetworkOperatesStable() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkOperatesStable", reflect.TypeOf((*MockMonitor)(nil).NetworkOperatesStable))
 }
 
+// NetworkSLA mocks base method.
+func (m *MockMonitor) NetworkSLA(from, to time.Time) (SLAReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkSLA", from, to)
+	ret0, _ := ret[0].(SLAReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetworkSLA indicates an expected call of NetworkSLA.
+func (mr *MockMonitorMockRecorder) NetworkSLA(from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkSLA", reflect.TypeOf((*MockMonitor)(nil).NetworkSLA), from, to)
+}
+
 // NetworkStatusInfo mocks base method.
 func (m *MockMonitor) NetworkStatusInfo() NetworkStatusInfo {
 	m.ctrl.T.Helper()
@@ -103,3 +275,18 @@ func (mr *MockMonitorMockRecorder) State() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockMonitor)(nil).State))
 }
+
+// WatchStatus mocks base method.
+func (m *MockMonitor) WatchStatus() (<-chan NetworkStatusInfo, func()) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchStatus")
+	ret0, _ := ret[0].(<-chan NetworkStatusInfo)
+	ret1, _ := ret[1].(func())
+	return ret0, ret1
+}
+
+// WatchStatus indicates an expected call of WatchStatus.
+func (mr *MockMonitorMockRecorder) WatchStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchStatus", reflect.TypeOf((*MockMonitor)(nil).WatchStatus))
+}
@@ -1,11 +1,18 @@
 package monitor
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/nickeskov/netmon/pkg/clock"
+	"github.com/nickeskov/netmon/pkg/clock/clocktest"
+	"github.com/nickeskov/netmon/pkg/fakestats"
 	"github.com/stretchr/testify/require"
 )
 
@@ -14,10 +21,10 @@ func TestNetworkMonitor_CheckNodes(t *testing.T) {
 	defer ctrl.Finish()
 
 	scraperMock := NewMockNodesStatsScrapper(ctrl)
-	scraperMock.EXPECT().ScrapeNodeStats().Times(1).Return(
+	scraperMock.EXPECT().ScrapeNodeStats(gomock.Any()).Times(1).Return(
 		nodesWithStats{
-			{nodeStats: nodeStats{Height: 11, NetByte: MainNetSchemeChar}},
-			{nodeStats: nodeStats{Height: 11, NetByte: MainNetSchemeChar}},
+			{nodeStats: nodeStats{Height: 11, StateHash: "aa", NetByte: MainNetSchemeChar}},
+			{nodeStats: nodeStats{Height: 11, StateHash: "aa", NetByte: MainNetSchemeChar}},
 			{nodeStats: nodeStats{Height: -1, NetByte: MainNetSchemeChar}},
 		},
 		nil,
@@ -37,21 +44,251 @@ func TestNetworkMonitor_CheckNodes(t *testing.T) {
 
 	now := time.Now()
 
-	err = mon.CheckNodes(now)
+	err = mon.CheckNodes(context.Background(), now)
 	require.NoError(t, err)
 
 	require.False(t, mon.NetworkOperatesStable())
 	require.Equal(t, mon.networkErrorStreak, 1)
 
 	expectedInfo := NetworkStatusInfo{
-		Updated: now,
-		Network: MainNetSchemeChar,
-		Status:  false,
-		Height:  11,
+		Updated:  now,
+		Network:  MainNetSchemeChar,
+		Status:   false,
+		Height:   11,
+		Severity: SeverityCritical,
+		Reasons: []SeverityReason{ // zero height and statehash criteria are alerted too
+			{Reason: CriterionStateHash, Severity: SeverityCritical, Since: now},
+			{Reason: CriterionNodesDown, Severity: SeverityDegraded, Since: now},
+			{Reason: CriterionNodesHeight, Severity: SeverityDegraded, Since: now},
+		},
 	}
 	require.Equal(t, expectedInfo, mon.NetworkStatusInfo())
 }
 
+func TestNetworkMonitor_CheckNodes_FakeStats(t *testing.T) {
+	srv := fakestats.NewServer()
+	defer srv.Close()
+	srv.AddNodes(string(MainNetSchemeChar), 100, "aa", "n1", "n2", "n3", "n4", "n5", "n6")
+	srv.AddNodes(string(TestNetSchemeChar), 10, "bb", "t1")
+
+	mon, err := NewNetworkMonitoring(
+		StateActive,
+		MainNetSchemeChar,
+		10,
+		NewNodesStatsScraperHTTP(srv.URL(), DefaultNodeStatsPollResponseSize),
+		1,
+		NetworkErrorCriteria{
+			NodesDown:   NodesDownCriterion{TotalDownNodesPart: 0.3},
+			NodesHeight: NodesHeightCriterion{HeightDiff: 5, RequireMinNodesOnHeight: 2},
+			StateHash: NodesStateHashCriterion{
+				MinStateHashGroupsOnSameHeight:   2,
+				MinValuableStateHashGroups:       2,
+				MinNodesInValuableStateHashGroup: 2,
+				RequireMinNodesOnHeight:          4,
+			},
+		},
+	)
+	require.NoError(t, err)
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		scenario func()
+		stable   bool
+	}{
+		{"AllNodesWork", func() {}, true},
+		{"NodesDown", func() { srv.NodesDown("n1", "n2") }, false},
+		{"NodesRecovered", func() { srv.SetHeight(100, "n1", "n2"); srv.Fork("aa", "n1", "n2") }, true},
+		{"HeightLag", func() { srv.LagHeight(10, "n1", "n2") }, false},
+		{"HeightRecovered", func() { srv.SetHeight(100, "n1", "n2") }, true},
+		{"StateHashFork", func() { srv.Fork("cc", "n1", "n2") }, false},
+		{"ForkResolved", func() { srv.Fork("aa", "n1", "n2") }, true},
+	}
+	for i, tc := range tests {
+		tc.scenario()
+		require.NoError(t, mon.CheckNodes(context.Background(), now.Add(time.Duration(i)*time.Minute)), "failed testcase %q", tc.name)
+		require.Equal(t, tc.stable, mon.NetworkOperatesStable(), "failed testcase %q", tc.name)
+	}
+
+	srv.FailWithStatus(http.StatusInternalServerError, 1)
+	require.Error(t, mon.CheckNodes(context.Background(), now.Add(time.Hour)))
+	require.Equal(t, len(tests)+1, srv.Requests())
+}
+
+func TestNetworkMonitor_CheckNodes_NodesFilterAndRequiredNodes(t *testing.T) {
+	srv := fakestats.NewServer()
+	defer srv.Close()
+	srv.AddNodes(string(MainNetSchemeChar), 100, "aa", "n1.own.com", "n2.own.com", "n3.own.com", "n4.own.com")
+	srv.AddNodes(string(MainNetSchemeChar), 100, "aa", "other.org", "third-party.org")
+
+	mon, err := NewNetworkMonitoring(
+		StateActive,
+		MainNetSchemeChar,
+		10,
+		NewNodesStatsScraperHTTP(srv.URL(), DefaultNodeStatsPollResponseSize),
+		1,
+		NetworkErrorCriteria{
+			NodesDown:     NodesDownCriterion{TotalDownNodesPart: 0.3},
+			NodesHeight:   NodesHeightCriterion{HeightDiff: 5, RequireMinNodesOnHeight: 2},
+			RequiredNodes: RequiredNodesCriterion{Nodes: []string{"n1.own.com"}, MaxHeightLag: 2},
+			StateHash: NodesStateHashCriterion{
+				MinStateHashGroupsOnSameHeight:   2,
+				MinValuableStateHashGroups:       2,
+				MinNodesInValuableStateHashGroup: 2,
+				RequireMinNodesOnHeight:          4,
+			},
+		},
+		WithNodesFilter(NodesFilter{Include: []string{"*.own.com", "*.org"}, Exclude: []string{"third-party.*"}}),
+	)
+	require.NoError(t, err)
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		scenario func()
+		stable   bool
+	}{
+		{"AllNodesWork", func() {}, true},
+		{"ExcludedNodeDown", func() { srv.NodesDown("third-party.org") }, true},
+		{"RequiredNodeLagsWithinThreshold", func() { srv.LagHeight(2, "n1.own.com") }, true},
+		{"RequiredNodeLags", func() { srv.LagHeight(3, "n1.own.com") }, false},
+		{"RequiredNodeRecovered", func() { srv.SetHeight(100, "n1.own.com") }, true},
+		{"RequiredNodeDown", func() { srv.NodesDown("n1.own.com") }, false},
+	}
+	for i, tc := range tests {
+		tc.scenario()
+		require.NoError(t, mon.CheckNodes(context.Background(), now.Add(time.Duration(i)*time.Minute)), "failed testcase %q", tc.name)
+		require.Equal(t, tc.stable, mon.NetworkOperatesStable(), "failed testcase %q", tc.name)
+	}
+	require.NotContains(t, mon.statsHistory.Front().nodes.Domains(), "third-party.org")
+
+	_, err = NewNetworkMonitoring(StateActive, MainNetSchemeChar, 10, nil, 1, NetworkErrorCriteria{},
+		WithNodesFilter(NodesFilter{Exclude: []string{"[a-"}}),
+	)
+	require.Error(t, err)
+	_, err = NewNetworkMonitoring(StateActive, MainNetSchemeChar, 10, nil, 1,
+		NetworkErrorCriteria{RequiredNodes: RequiredNodesCriterion{Nodes: []string{"n1.own.com", "third-party.org"}}},
+		WithNodesFilter(NodesFilter{Include: []string{"*.own.com", "*.org"}, Exclude: []string{"third-party.*"}}),
+	)
+	require.EqualError(t, err, `required node "third-party.org" is excluded by the nodes filter`)
+}
+
+func TestNetworkMonitor_Run(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const pollInterval = time.Minute
+	start := time.Date(2021, 12, 2, 19, 0, 0, 0, time.UTC)
+	fakeClock := clocktest.NewFakeClock(start)
+	// the previous run has been operational till the crash
+	store := NewTransitionStore(nil, []StatusTransition{
+		{Timestamp: start.Add(-time.Hour), Status: NetworkStatusOperational},
+	})
+
+	scraperMock := NewMockNodesStatsScrapper(ctrl)
+	scraperMock.EXPECT().ScrapeNodeStats(gomock.Any()).Times(2).Return(
+		nodesWithStats{
+			{nodeStats: nodeStats{Height: 11, NetByte: MainNetSchemeChar}},
+			{nodeStats: nodeStats{Height: 11, NetByte: MainNetSchemeChar}},
+		},
+		nil,
+	)
+
+	mon, err := NewNetworkMonitoring(
+		StateActive,
+		MainNetSchemeChar,
+		10,
+		scraperMock,
+		1,
+		NetworkErrorCriteria{
+			NodesDown: NodesDownCriterion{TotalDownNodesPart: 0.3},
+		},
+		WithClock(fakeClock),
+		WithTransitionStore(store),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := mon.RunInBackground(ctx, pollInterval)
+
+	fakeClock.BlockUntilWaiters(1)
+	require.Equal(t, start, mon.NetworkStatusInfo().Updated)
+	require.Equal(t, LoopInfo{
+		Started:              start,
+		LastIteration:        start,
+		PollInterval:         pollInterval,
+		LastSuccessfulScrape: start,
+		State:                StateActive.String(),
+	}, mon.LoopInfo())
+
+	fakeClock.Advance(pollInterval)
+	fakeClock.BlockUntilWaiters(1)
+	require.Equal(t, start.Add(pollInterval), mon.NetworkStatusInfo().Updated)
+	require.Equal(t, 2, mon.statsHistory.Len())
+	require.Equal(t, start.Add(pollInterval), mon.LoopInfo().LastIteration)
+
+	fakeClock.Advance(pollInterval / 2)
+	cancel()
+	<-done
+	// network isn't monitored before the first check and after the stop
+	require.Equal(t, []StatusTransition{
+		{Timestamp: start.Add(-time.Hour), Status: NetworkStatusOperational},
+		{Timestamp: start, Status: NetworkStatusUnknown},
+		{Timestamp: start, Status: NetworkStatusDegraded},
+		{Timestamp: start.Add(3 * pollInterval / 2), Status: NetworkStatusUnknown},
+	}, store.Transitions(start.Add(-time.Hour), start.Add(time.Hour)))
+}
+
+func TestNetworkMonitor_LastSeenFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2021, 12, 2, 19, 0, 0, 0, time.UTC)
+	lastSeenFile := filepath.Join(t.TempDir(), "transitions.lastseen")
+	lastSeen, err := readLastSeen(lastSeenFile)
+	require.NoError(t, err)
+	require.True(t, lastSeen.IsZero())
+
+	// the previous run has crashed after the last seen time
+	require.NoError(t, writeLastSeen(lastSeenFile, start.Add(10*time.Minute)))
+	store := NewTransitionStore(nil, []StatusTransition{{Timestamp: start, Status: NetworkStatusOperational}})
+	newMonitor := func(scraper NodesStatsScrapper, clk clock.Clock) *NetworkMonitor {
+		mon, err := NewNetworkMonitoring(StateActive, MainNetSchemeChar, 10, scraper, 1, NetworkErrorCriteria{},
+			WithClock(clk),
+			WithTransitionStore(store),
+			WithLastSeenFile(lastSeenFile),
+		)
+		require.NoError(t, err)
+		return &mon
+	}
+	newMonitor(nil, clock.Real())
+	newMonitor(nil, clock.Real()) // unknown status has been already recorded
+	require.Equal(t, []StatusTransition{
+		{Timestamp: start, Status: NetworkStatusOperational},
+		{Timestamp: start.Add(10 * time.Minute), Status: NetworkStatusUnknown},
+	}, store.Transitions(start, start.Add(time.Hour)))
+
+	// the loop keeps the last seen time
+	scraperMock := NewMockNodesStatsScrapper(ctrl)
+	scraperMock.EXPECT().ScrapeNodeStats(gomock.Any()).Times(1).Return(nil, fmt.Errorf("unavailable"))
+	fakeClock := clocktest.NewFakeClock(start.Add(time.Hour))
+	mon := newMonitor(scraperMock, fakeClock)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := mon.RunInBackground(ctx, time.Minute)
+	fakeClock.BlockUntilWaiters(1)
+	lastSeen, err = readLastSeen(lastSeenFile)
+	require.NoError(t, err)
+	require.Equal(t, start.Add(time.Hour), lastSeen)
+	cancel()
+	<-done
+
+	require.NoError(t, os.WriteFile(lastSeenFile, []byte("yesterday"), 0644))
+	_, err = NewNetworkMonitoring(StateActive, MainNetSchemeChar, 10, nil, 1, NetworkErrorCriteria{},
+		WithLastSeenFile(lastSeenFile),
+	)
+	require.Error(t, err)
+}
+
 func TestNetworkMonitor_ChangeState(t *testing.T) {
 	mon, err := NewNetworkMonitoring(
 		StateActive,
@@ -148,16 +385,56 @@ func TestNetworkMonitor_NetworkStatusInfo(t *testing.T) {
 		require.Nil(t, back)
 
 		expected := NetworkStatusInfo{
-			Updated: now,
-			Network: tc.network,
-			Status:  tc.operatesStable,
-			Height:  tc.height,
+			Updated:  now,
+			Network:  tc.network,
+			Status:   tc.operatesStable,
+			Height:   tc.height,
+			Severity: SeverityOK,
+			Reasons:  []SeverityReason{},
 		}
 		actual := mon.NetworkStatusInfo()
 		require.Equal(t, expected, actual, "failed testcase #%d", i)
 	}
 }
 
+func TestNetworkMonitor_NetworkNodesInfo(t *testing.T) {
+	mon, err := NewNetworkMonitoring(
+		StateActive,
+		MainNetSchemeChar,
+		10,
+		nil,
+		5,
+		NetworkErrorCriteria{NodesLag: NodesHeightLagCriterion{MaxLag: 2}},
+	)
+	require.NoError(t, err)
+	require.Equal(t, NetworkNodesInfo{Network: MainNetSchemeChar, Nodes: []NodeInfo{}}, mon.NetworkNodesInfo())
+
+	now := time.Now()
+	back := mon.statsHistory.PushFront(&statsDataSnapshot{
+		snapshotCreationTime: now,
+		maxHeight:            10,
+		nodes: nodesWithStats{
+			{NodeDomain: "b", nodeStats: nodeStats{Height: -1}},
+			{NodeDomain: "a", nodeStats: nodeStats{Height: 10, StateHash: "11"}},
+			{NodeDomain: "c", nodeStats: nodeStats{Height: 8, StateHash: "22"}},
+			{NodeDomain: "d", nodeStats: nodeStats{Height: 7, StateHash: "33"}},
+		},
+	})
+	require.Nil(t, back)
+
+	expected := NetworkNodesInfo{
+		Updated: now,
+		Network: MainNetSchemeChar,
+		Nodes: []NodeInfo{
+			{Domain: "a", Height: 10, StateHash: "11", Class: NodeClassValid},
+			{Domain: "b", Height: -1, Class: NodeClassDown},
+			{Domain: "c", Height: 8, StateHash: "22", Class: NodeClassValid, Lag: 2},
+			{Domain: "d", Height: 7, StateHash: "33", Class: NodeClassValid, Lag: 3, Lagging: true},
+		},
+	}
+	require.Equal(t, expected, mon.NetworkNodesInfo())
+}
+
 func TestNetworkMonitoringState(t *testing.T) {
 	tests := []struct {
 		state NetworkMonitoringState
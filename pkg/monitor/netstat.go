@@ -1,25 +1,69 @@
 package monitor
 
 import (
+	"encoding/json"
 	"math"
+	"sort"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
+const (
+	MalformedNodesIgnore  MalformedNodesPolicy = "ignore"  // treated as up nodes: counted in total nodes amount only
+	MalformedNodesAsDown  MalformedNodesPolicy = "down"    // counted as down nodes
+	MalformedNodesExclude MalformedNodesPolicy = "exclude" // excluded from total nodes amount
+)
+
+// MalformedNodesPolicy defines how malformed nodes are treated by NodesDownCriterion.
+// Empty value is the same as MalformedNodesIgnore. Note that ignored malformed nodes lower the down nodes part the same
+// way as up nodes do, use MalformedNodesExclude to leave them out of the part completely.
+type MalformedNodesPolicy string
+
+func (p MalformedNodesPolicy) Validate() error {
+	switch p {
+	case "", MalformedNodesIgnore, MalformedNodesAsDown, MalformedNodesExclude:
+		return nil
+	default:
+		return errors.Errorf("invalid malformed nodes policy %q", p)
+	}
+}
+
 type NodesDownCriterion struct {
-	TotalDownNodesPart float64
+	TotalDownNodesPart float64              `json:"total_down_nodes_part"`
+	MalformedNodes     MalformedNodesPolicy `json:"malformed_nodes,omitempty"`
 }
 
 func (c *NodesDownCriterion) Validate() error {
 	if c.TotalDownNodesPart <= 0 || c.TotalDownNodesPart >= 1 {
 		return errors.Errorf("NodesDownCriterion.TotalDownNodesPart value should be 0.0 < n < 1.0")
 	}
+	if err := c.MalformedNodes.Validate(); err != nil {
+		return errors.Wrap(err, "NodesDownCriterion.MalformedNodes")
+	}
+	return nil
+}
+
+// NodesSyncingCriterion is optional, it's disabled if TotalSyncingNodesPart is zero.
+type NodesSyncingCriterion struct {
+	TotalSyncingNodesPart float64 `json:"total_syncing_nodes_part"`
+}
+
+func (c *NodesSyncingCriterion) Enabled() bool {
+	return c.TotalSyncingNodesPart != 0
+}
+
+func (c *NodesSyncingCriterion) Validate() error {
+	if c.TotalSyncingNodesPart < 0 || c.TotalSyncingNodesPart >= 1 {
+		return errors.Errorf("NodesSyncingCriterion.TotalSyncingNodesPart value should be 0.0 <= n < 1.0")
+	}
 	return nil
 }
 
 type NodesHeightCriterion struct {
-	HeightDiff              int
-	RequireMinNodesOnHeight int // minimum required count of nodes on the same height to activate this criterion
+	HeightDiff int `json:"height_diff"`
+	// minimum required count of nodes on the same height to activate this criterion
+	RequireMinNodesOnHeight int `json:"require_min_nodes_on_height"`
 }
 
 func (c *NodesHeightCriterion) Validate() error {
@@ -33,10 +77,11 @@ func (c *NodesHeightCriterion) Validate() error {
 }
 
 type NodesStateHashCriterion struct {
-	MinStateHashGroupsOnSameHeight   int
-	MinValuableStateHashGroups       int
-	MinNodesInValuableStateHashGroup int
-	RequireMinNodesOnHeight          int // minimum required count of nodes on the same height to activate this criterion
+	MinStateHashGroupsOnSameHeight   int `json:"min_statehash_groups_on_same_height"`
+	MinValuableStateHashGroups       int `json:"min_valuable_statehash_groups"`
+	MinNodesInValuableStateHashGroup int `json:"min_nodes_in_valuable_statehash_group"`
+	// minimum required count of nodes on the same height to activate this criterion
+	RequireMinNodesOnHeight int `json:"require_min_nodes_on_height"`
 }
 
 func (c *NodesStateHashCriterion) Validate() error {
@@ -55,22 +100,195 @@ func (c *NodesStateHashCriterion) Validate() error {
 	return nil
 }
 
+// NodesHeightLagCriterion is optional, it's disabled if MaxLag is zero.
+// Node is lagging if it's behind the max height more than MaxLag blocks. The criterion is generated if amount of
+// lagging nodes is greater than MaxLaggingNodes or if part of lagging nodes among working nodes is greater than
+// MaxLaggingNodesPart. Zero thresholds are disabled, if both are zero, any lagging node generates the criterion.
+type NodesHeightLagCriterion struct {
+	MaxLag              int     `json:"max_lag"`
+	MaxLaggingNodes     int     `json:"max_lagging_nodes"`
+	MaxLaggingNodesPart float64 `json:"max_lagging_nodes_part"`
+}
+
+func (c *NodesHeightLagCriterion) Enabled() bool {
+	return c.MaxLag != 0
+}
+
+func (c *NodesHeightLagCriterion) Validate() error {
+	if c.MaxLag < 0 {
+		return errors.Errorf("NodesHeightLagCriterion.MaxLag value should be non negative")
+	}
+	if c.MaxLaggingNodes < 0 {
+		return errors.Errorf("NodesHeightLagCriterion.MaxLaggingNodes value should be non negative")
+	}
+	if c.MaxLaggingNodesPart < 0 || c.MaxLaggingNodesPart >= 1 {
+		return errors.Errorf("NodesHeightLagCriterion.MaxLaggingNodesPart value should be 0.0 <= n < 1.0")
+	}
+	return nil
+}
+
+// NodesFlappingCriterion is optional, it's disabled if MaxTransitions is zero.
+// Node is flapping if it has changed between working and down states more than MaxTransitions times in the stats
+// history window. The criterion is generated if amount of flapping nodes is greater than MaxFlappingNodes.
+type NodesFlappingCriterion struct {
+	MaxTransitions   int `json:"max_transitions"`
+	MaxFlappingNodes int `json:"max_flapping_nodes"`
+}
+
+func (c *NodesFlappingCriterion) Enabled() bool {
+	return c.MaxTransitions != 0
+}
+
+func (c *NodesFlappingCriterion) Validate() error {
+	if c.MaxTransitions < 0 {
+		return errors.Errorf("NodesFlappingCriterion.MaxTransitions value should be non negative")
+	}
+	if c.MaxFlappingNodes < 0 {
+		return errors.Errorf("NodesFlappingCriterion.MaxFlappingNodes value should be non negative")
+	}
+	return nil
+}
+
+// BlockIntervalCriterion is optional, it's disabled if MaxDeviation is zero.
+// It's generated if the average block interval over Window deviates from Expected by more than MaxDeviation part,
+// e.g. 0.5 means that intervals shorter than 0.5*Expected or longer than 1.5*Expected are alerted.
+type BlockIntervalCriterion struct {
+	Expected     time.Duration
+	MaxDeviation float64
+	Window       time.Duration
+}
+
+// blockIntervalCriterionJSON is the JSON form of BlockIntervalCriterion, durations are strings like "1m0s".
+type blockIntervalCriterionJSON struct {
+	Expected     string  `json:"expected"`
+	MaxDeviation float64 `json:"max_deviation"`
+	Window       string  `json:"window"`
+}
+
+func (c BlockIntervalCriterion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(blockIntervalCriterionJSON{
+		Expected:     c.Expected.String(),
+		MaxDeviation: c.MaxDeviation,
+		Window:       c.Window.String(),
+	})
+}
+
+func (c *BlockIntervalCriterion) UnmarshalJSON(data []byte) error {
+	var criterion blockIntervalCriterionJSON
+	if err := json.Unmarshal(data, &criterion); err != nil {
+		return err
+	}
+	var (
+		parsed BlockIntervalCriterion
+		err    error
+	)
+	if criterion.Expected != "" {
+		if parsed.Expected, err = time.ParseDuration(criterion.Expected); err != nil {
+			return errors.Wrap(err, "BlockIntervalCriterion.Expected")
+		}
+	}
+	if criterion.Window != "" {
+		if parsed.Window, err = time.ParseDuration(criterion.Window); err != nil {
+			return errors.Wrap(err, "BlockIntervalCriterion.Window")
+		}
+	}
+	parsed.MaxDeviation = criterion.MaxDeviation
+	*c = parsed
+	return nil
+}
+
+func (c *BlockIntervalCriterion) Enabled() bool {
+	return c.MaxDeviation != 0
+}
+
+func (c *BlockIntervalCriterion) Validate() error {
+	if c.MaxDeviation < 0 {
+		return errors.Errorf("BlockIntervalCriterion.MaxDeviation value should be non negative")
+	}
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Expected <= 0 {
+		return errors.Errorf("BlockIntervalCriterion.Expected value should be positive")
+	}
+	if c.Window < c.Expected {
+		return errors.Errorf("BlockIntervalCriterion.Window value should be not less than BlockIntervalCriterion.Expected")
+	}
+	return nil
+}
+
+// RequiredNodesCriterion is optional, it's disabled if Nodes is empty.
+// It's generated if any of required nodes is down, missing or lags behind the max height more than MaxHeightLag.
+type RequiredNodesCriterion struct {
+	Nodes        []string `json:"nodes"`
+	MaxHeightLag int      `json:"max_height_lag"`
+}
+
+func (c *RequiredNodesCriterion) Enabled() bool {
+	return len(c.Nodes) != 0
+}
+
+func (c *RequiredNodesCriterion) Validate() error {
+	if c.MaxHeightLag < 0 {
+		return errors.Errorf("RequiredNodesCriterion.MaxHeightLag value should be non negative")
+	}
+	for _, domain := range c.Nodes {
+		if domain == "" {
+			return errors.Errorf("RequiredNodesCriterion.Nodes shouldn't contain empty domains")
+		}
+	}
+	return nil
+}
+
+// NetworkErrorCriteria fields are named in JSON by the criteria names, e.g. CriterionNodesDown.
 type NetworkErrorCriteria struct {
-	NodesDown   NodesDownCriterion
-	NodesHeight NodesHeightCriterion
-	StateHash   NodesStateHashCriterion
+	NodesDown     NodesDownCriterion      `json:"nodes_down"`
+	NodesSyncing  NodesSyncingCriterion   `json:"nodes_syncing"`
+	NodesHeight   NodesHeightCriterion    `json:"nodes_height"`
+	NodesLag      NodesHeightLagCriterion `json:"nodes_lag"`
+	NodesFlapping NodesFlappingCriterion  `json:"nodes_flapping"`
+	BlockInterval BlockIntervalCriterion  `json:"block_interval"`
+	Anomaly       AnomalyCriterion        `json:"anomaly"`
+	StateHash     NodesStateHashCriterion `json:"statehash"`
+	RequiredNodes RequiredNodesCriterion  `json:"required_nodes"`
+	// NodeWeights sets weights of nodes by domain for down nodes and statehash criteria.
+	// Nodes which are absent in the map have weight 1.
+	NodeWeights map[string]float64 `json:"node_weights,omitempty"`
 }
 
 func (c *NetworkErrorCriteria) Validate() error {
 	if err := c.NodesDown.Validate(); err != nil {
 		return err
 	}
+	if err := c.NodesSyncing.Validate(); err != nil {
+		return err
+	}
 	if err := c.NodesHeight.Validate(); err != nil {
 		return err
 	}
 	if err := c.StateHash.Validate(); err != nil {
 		return err
 	}
+	if err := c.NodesLag.Validate(); err != nil {
+		return err
+	}
+	if err := c.NodesFlapping.Validate(); err != nil {
+		return err
+	}
+	if err := c.BlockInterval.Validate(); err != nil {
+		return err
+	}
+	if err := c.Anomaly.Validate(); err != nil {
+		return err
+	}
+	if err := c.RequiredNodes.Validate(); err != nil {
+		return err
+	}
+	for domain, weight := range c.NodeWeights {
+		if weight <= 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+			return errors.Errorf("NetworkErrorCriteria.NodeWeights: weight of node %q should be positive", domain)
+		}
+	}
 	return nil
 }
 
@@ -78,6 +296,8 @@ type netstatCalculator struct {
 	criteria             NetworkErrorCriteria
 	allNodes             nodesWithStats
 	downNodes            nodesWithStats
+	malformedNodes       nodesWithStats
+	syncingNodes         nodesWithStats
 	workingNodes         nodesWithStats
 	workingNodesOnHeight map[int]nodesWithStats
 }
@@ -91,16 +311,52 @@ func newNetstatCalculator(criteria NetworkErrorCriteria, allNodes nodesWithStats
 		criteria:             criteria,
 		allNodes:             allNodes,
 		downNodes:            allNodes.DownNodes(),
+		malformedNodes:       allNodes.MalformedNodes(),
+		syncingNodes:         allNodes.SyncingNodes(),
 		workingNodes:         workingNodes,
 		workingNodesOnHeight: workingNodes.SplitByHeight(),
 	}, nil
 }
 
+// weight returns summed weight of the nodes.
+func (n *netstatCalculator) weight(nodes nodesWithStats) float64 {
+	return nodesWeight(nodes, n.criteria.NodeWeights)
+}
+
+// downNodesWeights returns summed weights of down nodes and of all nodes which are taken into account.
+// Syncing nodes are neither working nor down, so they are excluded from the total nodes amount.
+// Malformed nodes are counted according to the criterion policy, they are treated as up nodes by default.
+func (n *netstatCalculator) downNodesWeights() (downNodes, totalNodes float64) {
+	downNodes, totalNodes = n.weight(n.downNodes), n.weight(n.allNodes)-n.weight(n.syncingNodes)
+	switch n.criteria.NodesDown.MalformedNodes {
+	case MalformedNodesAsDown:
+		downNodes += n.weight(n.malformedNodes)
+	case MalformedNodesExclude:
+		totalNodes -= n.weight(n.malformedNodes)
+	}
+	return downNodes, totalNodes
+}
+
+// AlertDownNodesCriterion checks down nodes weighted part.
 func (n *netstatCalculator) AlertDownNodesCriterion() bool {
-	totalDownPart := float64(len(n.downNodes)) / float64(len(n.allNodes))
+	downNodes, totalNodes := n.downNodesWeights()
+	if totalNodes <= 0 {
+		// no node is down if all nodes are syncing, that's covered by the syncing criterion,
+		// but excluded malformed nodes can't be trusted
+		return len(n.malformedNodes) != 0
+	}
+	totalDownPart := downNodes / totalNodes
 	return totalDownPart >= n.criteria.NodesDown.TotalDownNodesPart
 }
 
+func (n *netstatCalculator) AlertSyncingNodesCriterion() bool {
+	if !n.criteria.NodesSyncing.Enabled() {
+		return false
+	}
+	totalSyncingPart := float64(len(n.syncingNodes)) / float64(len(n.allNodes))
+	return totalSyncingPart >= n.criteria.NodesSyncing.TotalSyncingNodesPart
+}
+
 func (n *netstatCalculator) AlertHeightCriterion() bool {
 	minHeight := math.MaxInt
 	maxHeight := math.MinInt
@@ -141,9 +397,9 @@ func (n *netstatCalculator) AlertStateHashCriterion() bool {
 		}
 
 		valuableGroupsCnt := 0
-		// check second criterion, count valuable groups
+		// check second criterion, count valuable groups by summed weight of their nodes
 		for _, nodesOnHeightWithSameStateHash := range splitByStateHash {
-			if len(nodesOnHeightWithSameStateHash) >= n.criteria.StateHash.MinNodesInValuableStateHashGroup {
+			if n.weight(nodesOnHeightWithSameStateHash) >= float64(n.criteria.StateHash.MinNodesInValuableStateHashGroup) {
 				valuableGroupsCnt++
 			}
 		}
@@ -155,6 +411,116 @@ func (n *netstatCalculator) AlertStateHashCriterion() bool {
 	return false
 }
 
+// NodeLag is the amount of blocks by which the node is behind the max height.
+type NodeLag struct {
+	Domain string `json:"domain"`
+	Lag    int    `json:"lag"`
+}
+
+// LaggingNodes returns working nodes which are behind the max height more than NodesLag.MaxLag blocks,
+// sorted by lag in descending order.
+func (n *netstatCalculator) LaggingNodes() []NodeLag {
+	if !n.criteria.NodesLag.Enabled() {
+		return nil
+	}
+	maxHeight := n.CurrentMaxHeight()
+	var lagging []NodeLag
+	for _, node := range n.workingNodes {
+		if lag := maxHeight - node.Height; lag > n.criteria.NodesLag.MaxLag {
+			lagging = append(lagging, NodeLag{Domain: node.NodeDomain, Lag: lag})
+		}
+	}
+	sort.Slice(lagging, func(i, j int) bool {
+		if lagging[i].Lag != lagging[j].Lag {
+			return lagging[i].Lag > lagging[j].Lag
+		}
+		return lagging[i].Domain < lagging[j].Domain
+	})
+	return lagging
+}
+
+func (n *netstatCalculator) AlertHeightLagCriterion() bool {
+	return n.alertHeightLagCriterion(n.LaggingNodes())
+}
+
+func (n *netstatCalculator) alertHeightLagCriterion(lagging []NodeLag) bool {
+	criterion := n.criteria.NodesLag
+	if !criterion.Enabled() || len(lagging) == 0 {
+		return false
+	}
+	if criterion.MaxLaggingNodes == 0 && criterion.MaxLaggingNodesPart == 0 {
+		return true
+	}
+	if criterion.MaxLaggingNodes != 0 && len(lagging) > criterion.MaxLaggingNodes {
+		return true
+	}
+	laggingPart := float64(len(lagging)) / float64(len(n.workingNodes))
+	return criterion.MaxLaggingNodesPart != 0 && laggingPart > criterion.MaxLaggingNodesPart
+}
+
+// FailedRequiredNodes returns domains of required nodes which aren't working (down, syncing, malformed or missing)
+// or are lagging.
+func (n *netstatCalculator) FailedRequiredNodes() []string {
+	if !n.criteria.RequiredNodes.Enabled() {
+		return nil
+	}
+	nodes := make(map[string]*nodeWithStats, len(n.workingNodes))
+	for i := range n.workingNodes {
+		nodes[n.workingNodes[i].NodeDomain] = &n.workingNodes[i]
+	}
+	maxHeight := n.CurrentMaxHeight()
+	var failed []string
+	for _, domain := range n.criteria.RequiredNodes.Nodes {
+		node, ok := nodes[domain]
+		if !ok || maxHeight-node.Height > n.criteria.RequiredNodes.MaxHeightLag {
+			failed = append(failed, domain)
+		}
+	}
+	return failed
+}
+
+func (n *netstatCalculator) AlertRequiredNodesCriterion() bool {
+	return len(n.FailedRequiredNodes()) != 0
+}
+
+// DownNodesPart returns weighted part of down nodes as it's calculated by the down nodes criterion.
+// It's zero if all nodes are syncing or excluded.
+func (n *netstatCalculator) DownNodesPart() float64 {
+	downNodes, totalNodes := n.downNodesWeights()
+	if totalNodes <= 0 {
+		return 0
+	}
+	return downNodes / totalNodes
+}
+
+// HeightSpread returns difference between max and min heights of working nodes.
+func (n *netstatCalculator) HeightSpread() int {
+	if len(n.workingNodesOnHeight) == 0 {
+		return 0
+	}
+	minHeight, maxHeight := math.MaxInt, math.MinInt
+	for height := range n.workingNodesOnHeight {
+		if height < minHeight {
+			minHeight = height
+		}
+		if height > maxHeight {
+			maxHeight = height
+		}
+	}
+	return maxHeight - minHeight
+}
+
+// MaxStateHashGroups returns max amount of statehash groups on the same height.
+func (n *netstatCalculator) MaxStateHashGroups() int {
+	maxGroups := 0
+	for _, nodesOnHeight := range n.workingNodesOnHeight {
+		if groups := len(nodesOnHeight.SplitByStateHash()); groups > maxGroups {
+			maxGroups = groups
+		}
+	}
+	return maxGroups
+}
+
 // CurrentMaxHeight returns current max height for chosen network.
 // If all nodes are down return (-1).
 func (n *netstatCalculator) CurrentMaxHeight() int {
@@ -30,6 +30,79 @@ func TestNetstatCalculator_AlertDownNodesCriterion(t *testing.T) {
 			},
 			expectedResult: false,
 		},
+		{
+			criteria: NetworkErrorCriteria{NodesDown: NodesDownCriterion{TotalDownNodesPart: 0.3}},
+			nodes: nodesWithStats{
+				{nodeStats: nodeStats{Height: 11, StateHash: "11"}},
+				{nodeStats: nodeStats{Height: 11, StateHash: "11"}},
+				{nodeStats: nodeStats{Height: -2}},
+			},
+			expectedResult: false,
+		},
+		{
+			criteria: NetworkErrorCriteria{NodesDown: NodesDownCriterion{
+				TotalDownNodesPart: 0.3,
+				MalformedNodes:     MalformedNodesAsDown,
+			}},
+			nodes: nodesWithStats{
+				{nodeStats: nodeStats{Height: 11, StateHash: "11"}},
+				{nodeStats: nodeStats{Height: 11, StateHash: "11"}},
+				{nodeStats: nodeStats{Height: -2}},
+			},
+			expectedResult: true,
+		},
+		{
+			criteria: NetworkErrorCriteria{NodesDown: NodesDownCriterion{
+				TotalDownNodesPart: 0.4,
+				MalformedNodes:     MalformedNodesExclude,
+			}},
+			nodes: nodesWithStats{
+				{nodeStats: nodeStats{Height: 11, StateHash: "11"}},
+				{nodeStats: nodeStats{Height: -1}},
+				{nodeStats: nodeStats{Height: 11}},
+			},
+			expectedResult: true,
+		},
+		{
+			criteria: NetworkErrorCriteria{NodesDown: NodesDownCriterion{
+				TotalDownNodesPart: 0.4,
+				MalformedNodes:     MalformedNodesIgnore,
+			}},
+			nodes: nodesWithStats{
+				{nodeStats: nodeStats{Height: 11, StateHash: "11"}},
+				{nodeStats: nodeStats{Height: -1}},
+				{nodeStats: nodeStats{Height: 11}},
+			},
+			expectedResult: false,
+		},
+		{
+			criteria: NetworkErrorCriteria{NodesDown: NodesDownCriterion{
+				TotalDownNodesPart: 0.4,
+				MalformedNodes:     MalformedNodesExclude,
+			}},
+			nodes: nodesWithStats{
+				{nodeStats: nodeStats{Height: 11}},
+			},
+			expectedResult: true,
+		},
+		{
+			criteria: NetworkErrorCriteria{NodesDown: NodesDownCriterion{TotalDownNodesPart: 0.4}},
+			nodes: nodesWithStats{
+				{nodeStats: nodeStats{Height: 11, StateHash: "11"}},
+				{nodeStats: nodeStats{Height: 11, StateHash: "11"}},
+				{nodeStats: nodeStats{Height: -1}},
+				{nodeStats: nodeStats{Height: 0}},
+				{nodeStats: nodeStats{Height: 0}},
+			},
+			expectedResult: false, // 1/3, syncing nodes are excluded
+		},
+		{
+			criteria: NetworkErrorCriteria{NodesDown: NodesDownCriterion{TotalDownNodesPart: 0.4}},
+			nodes: nodesWithStats{
+				{nodeStats: nodeStats{Height: 0}},
+			},
+			expectedResult: false, // all nodes are syncing, no node is down
+		},
 	}
 
 	for i, tc := range tests {
@@ -40,6 +113,46 @@ func TestNetstatCalculator_AlertDownNodesCriterion(t *testing.T) {
 	}
 }
 
+func TestNetstatCalculator_AlertSyncingNodesCriterion(t *testing.T) {
+	tests := []struct {
+		criteria       NetworkErrorCriteria
+		nodes          nodesWithStats
+		expectedResult bool
+	}{
+		{
+			criteria: NetworkErrorCriteria{NodesSyncing: NodesSyncingCriterion{TotalSyncingNodesPart: 0.5}},
+			nodes: nodesWithStats{
+				{nodeStats: nodeStats{Height: 11}},
+				{nodeStats: nodeStats{Height: 0}},
+			},
+			expectedResult: true,
+		},
+		{
+			criteria: NetworkErrorCriteria{NodesSyncing: NodesSyncingCriterion{TotalSyncingNodesPart: 0.5}},
+			nodes: nodesWithStats{
+				{nodeStats: nodeStats{Height: 11}},
+				{nodeStats: nodeStats{Height: -1}},
+				{nodeStats: nodeStats{Height: 0}},
+			},
+			expectedResult: false,
+		},
+		{
+			criteria: NetworkErrorCriteria{}, // disabled
+			nodes: nodesWithStats{
+				{nodeStats: nodeStats{Height: 0}},
+			},
+			expectedResult: false,
+		},
+	}
+
+	for i, tc := range tests {
+		calc, err := newNetstatCalculator(tc.criteria, tc.nodes)
+		require.NoError(t, err)
+
+		require.Equal(t, tc.expectedResult, calc.AlertSyncingNodesCriterion(), "failed testcase #%d", i)
+	}
+}
+
 func TestNetstatCalculator_AlertHeightCriterion(t *testing.T) {
 	tests := []struct {
 		criteria       NetworkErrorCriteria
@@ -54,11 +167,11 @@ func TestNetstatCalculator_AlertHeightCriterion(t *testing.T) {
 				},
 			},
 			nodes: nodesWithStats{
-				{nodeStats: nodeStats{Height: 11}},
-				{nodeStats: nodeStats{Height: 11}},
-				{nodeStats: nodeStats{Height: 8}},
-				{nodeStats: nodeStats{Height: 4}},
-				{nodeStats: nodeStats{Height: 4}},
+				{nodeStats: nodeStats{Height: 11, StateHash: "aa"}},
+				{nodeStats: nodeStats{Height: 11, StateHash: "aa"}},
+				{nodeStats: nodeStats{Height: 8, StateHash: "aa"}},
+				{nodeStats: nodeStats{Height: 4, StateHash: "aa"}},
+				{nodeStats: nodeStats{Height: 4, StateHash: "aa"}},
 			},
 			expectedResult: true,
 		},
@@ -70,9 +183,9 @@ func TestNetstatCalculator_AlertHeightCriterion(t *testing.T) {
 				},
 			},
 			nodes: nodesWithStats{
-				{nodeStats: nodeStats{Height: 11}},
-				{nodeStats: nodeStats{Height: 11}},
-				{nodeStats: nodeStats{Height: 8}},
+				{nodeStats: nodeStats{Height: 11, StateHash: "aa"}},
+				{nodeStats: nodeStats{Height: 11, StateHash: "aa"}},
+				{nodeStats: nodeStats{Height: 8, StateHash: "aa"}},
 				{nodeStats: nodeStats{Height: -1}},
 			},
 			expectedResult: false,
@@ -85,9 +198,9 @@ func TestNetstatCalculator_AlertHeightCriterion(t *testing.T) {
 				},
 			},
 			nodes: nodesWithStats{
-				{nodeStats: nodeStats{Height: 11}},
-				{nodeStats: nodeStats{Height: 11}},
-				{nodeStats: nodeStats{Height: 4}},
+				{nodeStats: nodeStats{Height: 11, StateHash: "aa"}},
+				{nodeStats: nodeStats{Height: 11, StateHash: "aa"}},
+				{nodeStats: nodeStats{Height: 4, StateHash: "aa"}},
 				{nodeStats: nodeStats{Height: -1}},
 			},
 			expectedResult: false,
@@ -102,6 +215,35 @@ func TestNetstatCalculator_AlertHeightCriterion(t *testing.T) {
 	}
 }
 
+func TestNetstatCalculator_MalformedNodesAreNotWorking(t *testing.T) {
+	nodes := nodesWithStats{
+		{NodeDomain: "a", nodeStats: nodeStats{Height: 10, StateHash: "aa"}},
+		{NodeDomain: "b", nodeStats: nodeStats{Height: 10, StateHash: "aa"}},
+		{NodeDomain: "c", nodeStats: nodeStats{Height: 10, StateHash: "aa"}},
+		{NodeDomain: "d", nodeStats: nodeStats{Height: 10}}, // empty statehash
+		{NodeDomain: "e", nodeStats: nodeStats{Height: 10}}, // empty statehash
+		{NodeDomain: "f", nodeStats: nodeStats{Height: 10, StateHash: "ff", StateHashHeight: -2}},
+	}
+	criteria := NetworkErrorCriteria{
+		StateHash: NodesStateHashCriterion{
+			MinStateHashGroupsOnSameHeight:   2,
+			MinValuableStateHashGroups:       2,
+			MinNodesInValuableStateHashGroup: 2,
+			RequireMinNodesOnHeight:          3,
+		},
+	}
+	calc, err := newNetstatCalculator(criteria, nodes)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, calc.workingNodes.Domains())
+	require.Equal(t, []string{"d", "e", "f"}, calc.malformedNodes.Domains())
+	require.False(t, calc.AlertStateHashCriterion())
+
+	nodes[3].StateHash, nodes[4].StateHash = "bb", "bb"
+	calc, err = newNetstatCalculator(criteria, nodes)
+	require.NoError(t, err)
+	require.True(t, calc.AlertStateHashCriterion()) // the same nodes with valid statehashes make the fork
+}
+
 func TestNodesWithStats_SplitByStateHash(t *testing.T) {
 	tests := []struct {
 		criteria       NetworkErrorCriteria
@@ -198,11 +340,11 @@ func TestNetstatCalculator_CurrentMaxHeight(t *testing.T) {
 	}{
 		{
 			nodes: nodesWithStats{
-				{nodeStats: nodeStats{Height: 2}},
-				{nodeStats: nodeStats{Height: 2}},
-				{nodeStats: nodeStats{Height: 3}},
-				{nodeStats: nodeStats{Height: 4}},
-				{nodeStats: nodeStats{Height: 2}},
+				{nodeStats: nodeStats{Height: 2, StateHash: "aa"}},
+				{nodeStats: nodeStats{Height: 2, StateHash: "aa"}},
+				{nodeStats: nodeStats{Height: 3, StateHash: "aa"}},
+				{nodeStats: nodeStats{Height: 4, StateHash: "aa"}},
+				{nodeStats: nodeStats{Height: 2, StateHash: "aa"}},
 			},
 			expectedHeight: 4,
 		},
@@ -215,8 +357,8 @@ func TestNetstatCalculator_CurrentMaxHeight(t *testing.T) {
 		},
 		{
 			nodes: nodesWithStats{
-				{nodeStats: nodeStats{Height: 2}},
-				{nodeStats: nodeStats{Height: 3}},
+				{nodeStats: nodeStats{Height: 2, StateHash: "aa"}},
+				{nodeStats: nodeStats{Height: 3, StateHash: "aa"}},
 			},
 			expectedHeight: 3,
 		},
@@ -238,6 +380,29 @@ func TestNodesDownCriterion_Validate(t *testing.T) {
 		{NodesDownCriterion{TotalDownNodesPart: 0.5}, true},
 		{NodesDownCriterion{TotalDownNodesPart: 1.0}, false},
 		{NodesDownCriterion{TotalDownNodesPart: 2.0}, false},
+		{NodesDownCriterion{TotalDownNodesPart: 0.5, MalformedNodes: MalformedNodesAsDown}, true},
+		{NodesDownCriterion{TotalDownNodesPart: 0.5, MalformedNodes: MalformedNodesExclude}, true},
+		{NodesDownCriterion{TotalDownNodesPart: 0.5, MalformedNodes: "blah"}, false},
+	}
+	for _, tc := range tests {
+		err := tc.criterion.Validate()
+		if tc.ok {
+			require.NoError(t, err)
+		} else {
+			require.Error(t, err)
+		}
+	}
+}
+
+func TestNodesSyncingCriterion_Validate(t *testing.T) {
+	tests := []struct {
+		criterion NodesSyncingCriterion
+		ok        bool
+	}{
+		{NodesSyncingCriterion{TotalSyncingNodesPart: -1.0}, false},
+		{NodesSyncingCriterion{TotalSyncingNodesPart: 0.0}, true},
+		{NodesSyncingCriterion{TotalSyncingNodesPart: 0.5}, true},
+		{NodesSyncingCriterion{TotalSyncingNodesPart: 1.0}, false},
 	}
 	for _, tc := range tests {
 		err := tc.criterion.Validate()
@@ -397,6 +562,24 @@ func TestNetworkErrorCriteria_Validate(t *testing.T) {
 			},
 			ok: false,
 		},
+		{
+			criteria: NetworkErrorCriteria{
+				NodesDown:   NodesDownCriterion{TotalDownNodesPart: 0.3},
+				NodesHeight: NodesHeightCriterion{1, 1},
+				StateHash:   NodesStateHashCriterion{1, 1, 1, 1},
+				NodeWeights: map[string]float64{"a": 2.5, "b": 0.5},
+			},
+			ok: true,
+		},
+		{
+			criteria: NetworkErrorCriteria{
+				NodesDown:   NodesDownCriterion{TotalDownNodesPart: 0.3},
+				NodesHeight: NodesHeightCriterion{1, 1},
+				StateHash:   NodesStateHashCriterion{1, 1, 1, 1},
+				NodeWeights: map[string]float64{"a": 0},
+			},
+			ok: false,
+		},
 	}
 	for _, tc := range tests {
 		err := tc.criteria.Validate()
@@ -407,3 +590,150 @@ func TestNetworkErrorCriteria_Validate(t *testing.T) {
 		}
 	}
 }
+
+func TestNetstatCalculator_AlertRequiredNodesCriterion(t *testing.T) {
+	nodes := nodesWithStats{
+		{NodeDomain: "a", nodeStats: nodeStats{Height: 20, StateHash: "aa"}},
+		{NodeDomain: "b", nodeStats: nodeStats{Height: 18, StateHash: "aa"}},
+		{NodeDomain: "c", nodeStats: nodeStats{Height: -1}},
+		{NodeDomain: "d", nodeStats: nodeStats{Height: 0}},
+		{NodeDomain: "e", nodeStats: nodeStats{Height: 20}}, // malformed
+	}
+	tests := []struct {
+		criterion      RequiredNodesCriterion
+		expectedFailed []string
+	}{
+		{criterion: RequiredNodesCriterion{}, expectedFailed: nil}, // disabled
+		{criterion: RequiredNodesCriterion{Nodes: []string{"a", "b"}, MaxHeightLag: 2}, expectedFailed: nil},
+		{criterion: RequiredNodesCriterion{Nodes: []string{"a", "b"}, MaxHeightLag: 1}, expectedFailed: []string{"b"}},
+		{criterion: RequiredNodesCriterion{Nodes: []string{"a", "c", "d"}, MaxHeightLag: 5}, expectedFailed: []string{"c", "d"}},
+		{criterion: RequiredNodesCriterion{Nodes: []string{"a", "missing"}, MaxHeightLag: 5}, expectedFailed: []string{"missing"}},
+		{criterion: RequiredNodesCriterion{Nodes: []string{"a", "e"}, MaxHeightLag: 5}, expectedFailed: []string{"e"}},
+	}
+	for i, tc := range tests {
+		calc, err := newNetstatCalculator(NetworkErrorCriteria{RequiredNodes: tc.criterion}, nodes)
+		require.NoError(t, err)
+
+		require.Equal(t, tc.expectedFailed, calc.FailedRequiredNodes(), "failed testcase #%d", i)
+		require.Equal(t, len(tc.expectedFailed) != 0, calc.AlertRequiredNodesCriterion(), "failed testcase #%d", i)
+	}
+}
+
+func TestRequiredNodesCriterion_Validate(t *testing.T) {
+	require.NoError(t, (&RequiredNodesCriterion{}).Validate())
+	require.NoError(t, (&RequiredNodesCriterion{Nodes: []string{"a"}, MaxHeightLag: 3}).Validate())
+	require.Error(t, (&RequiredNodesCriterion{Nodes: []string{"a"}, MaxHeightLag: -1}).Validate())
+	require.Error(t, (&RequiredNodesCriterion{Nodes: []string{""}}).Validate())
+}
+
+func TestNetstatCalculator_WeightedNodes(t *testing.T) {
+	nodes := nodesWithStats{
+		{NodeDomain: "foundation", nodeStats: nodeStats{Height: -1}},
+		{NodeDomain: "pool", nodeStats: nodeStats{Height: 10, StateHash: "aa"}},
+		{NodeDomain: "a", nodeStats: nodeStats{Height: 10, StateHash: "aa"}},
+		{NodeDomain: "b", nodeStats: nodeStats{Height: 10, StateHash: "bb"}},
+		{NodeDomain: "c", nodeStats: nodeStats{Height: 10, StateHash: "cc"}},
+	}
+	criteria := NetworkErrorCriteria{
+		NodesDown: NodesDownCriterion{TotalDownNodesPart: 0.3},
+		StateHash: NodesStateHashCriterion{
+			MinStateHashGroupsOnSameHeight:   2,
+			MinValuableStateHashGroups:       2,
+			MinNodesInValuableStateHashGroup: 2,
+			RequireMinNodesOnHeight:          4,
+		},
+	}
+
+	// unweighted: 1/5 nodes are down and only "aa" group is valuable
+	calc, err := newNetstatCalculator(criteria, nodes)
+	require.NoError(t, err)
+	require.False(t, calc.AlertDownNodesCriterion())
+	require.False(t, calc.AlertStateHashCriterion())
+
+	// weighted: 3/8 of weight is down and "bb" group becomes valuable
+	criteria.NodeWeights = map[string]float64{"foundation": 3, "b": 2}
+	calc, err = newNetstatCalculator(criteria, nodes)
+	require.NoError(t, err)
+	require.True(t, calc.AlertDownNodesCriterion())
+	require.True(t, calc.AlertStateHashCriterion())
+	require.Equal(t, 3.0/8, calc.DownNodesPart()) // the anomaly indicator matches the criterion
+}
+
+func TestNetstatCalculator_DownNodesPart(t *testing.T) {
+	nodes := nodesWithStats{
+		{NodeDomain: "a", nodeStats: nodeStats{Height: 10, StateHash: "aa"}},
+		{NodeDomain: "b", nodeStats: nodeStats{Height: -1}},
+		{NodeDomain: "c", nodeStats: nodeStats{Height: 0}},
+		{NodeDomain: "d", nodeStats: nodeStats{Height: 0}},
+	}
+	calc, err := newNetstatCalculator(NetworkErrorCriteria{}, nodes)
+	require.NoError(t, err)
+	require.Equal(t, 0.5, calc.DownNodesPart()) // syncing nodes are excluded
+
+	calc, err = newNetstatCalculator(NetworkErrorCriteria{}, nodes[2:])
+	require.NoError(t, err)
+	require.Zero(t, calc.DownNodesPart())
+	require.False(t, calc.AlertDownNodesCriterion())
+}
+
+func TestNetstatCalculator_AlertHeightLagCriterion(t *testing.T) {
+	nodes := nodesWithStats{
+		{NodeDomain: "a", nodeStats: nodeStats{Height: 600, StateHash: "aa"}},
+		{NodeDomain: "b", nodeStats: nodeStats{Height: 599, StateHash: "aa"}},
+		{NodeDomain: "c", nodeStats: nodeStats{Height: 590, StateHash: "aa"}},
+		{NodeDomain: "d", nodeStats: nodeStats{Height: 100, StateHash: "aa"}},
+		{NodeDomain: "e", nodeStats: nodeStats{Height: -1}},
+	}
+	tests := []struct {
+		criterion       NodesHeightLagCriterion
+		expectedLagging []NodeLag
+		expectedResult  bool
+	}{
+		{criterion: NodesHeightLagCriterion{}, expectedLagging: nil, expectedResult: false}, // disabled
+		{
+			criterion:       NodesHeightLagCriterion{MaxLag: 5},
+			expectedLagging: []NodeLag{{Domain: "d", Lag: 500}, {Domain: "c", Lag: 10}},
+			expectedResult:  true,
+		},
+		{
+			criterion:       NodesHeightLagCriterion{MaxLag: 5, MaxLaggingNodes: 2},
+			expectedLagging: []NodeLag{{Domain: "d", Lag: 500}, {Domain: "c", Lag: 10}},
+			expectedResult:  false,
+		},
+		{
+			criterion:       NodesHeightLagCriterion{MaxLag: 5, MaxLaggingNodes: 2, MaxLaggingNodesPart: 0.4},
+			expectedLagging: []NodeLag{{Domain: "d", Lag: 500}, {Domain: "c", Lag: 10}},
+			expectedResult:  true, // 2 of 4 working nodes are lagging
+		},
+		{
+			criterion:       NodesHeightLagCriterion{MaxLag: 10, MaxLaggingNodes: 0},
+			expectedLagging: []NodeLag{{Domain: "d", Lag: 500}},
+			expectedResult:  true,
+		},
+		{
+			criterion:       NodesHeightLagCriterion{MaxLag: 5, MaxLaggingNodesPart: 0.5},
+			expectedLagging: []NodeLag{{Domain: "d", Lag: 500}, {Domain: "c", Lag: 10}},
+			expectedResult:  false, // only the share threshold is set, 2 of 4 working nodes aren't greater than it
+		},
+		{
+			criterion:       NodesHeightLagCriterion{MaxLag: 5, MaxLaggingNodesPart: 0.4},
+			expectedLagging: []NodeLag{{Domain: "d", Lag: 500}, {Domain: "c", Lag: 10}},
+			expectedResult:  true,
+		},
+	}
+	for i, tc := range tests {
+		calc, err := newNetstatCalculator(NetworkErrorCriteria{NodesLag: tc.criterion}, nodes)
+		require.NoError(t, err)
+
+		require.Equal(t, tc.expectedLagging, calc.LaggingNodes(), "failed testcase #%d", i)
+		require.Equal(t, tc.expectedResult, calc.AlertHeightLagCriterion(), "failed testcase #%d", i)
+	}
+}
+
+func TestNodesHeightLagCriterion_Validate(t *testing.T) {
+	require.NoError(t, (&NodesHeightLagCriterion{}).Validate())
+	require.NoError(t, (&NodesHeightLagCriterion{MaxLag: 100, MaxLaggingNodes: 1, MaxLaggingNodesPart: 0.2}).Validate())
+	require.Error(t, (&NodesHeightLagCriterion{MaxLag: -1}).Validate())
+	require.Error(t, (&NodesHeightLagCriterion{MaxLag: 1, MaxLaggingNodes: -1}).Validate())
+	require.Error(t, (&NodesHeightLagCriterion{MaxLag: 1, MaxLaggingNodesPart: 1}).Validate())
+}
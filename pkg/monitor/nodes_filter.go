@@ -0,0 +1,48 @@
+package monitor
+
+import (
+	"path"
+
+	"github.com/pkg/errors"
+)
+
+// NodesFilter selects monitored nodes by domain glob patterns, see path.Match for the patterns syntax.
+// If Include is empty, all nodes are included. Exclude patterns take precedence over Include patterns.
+type NodesFilter struct {
+	Include []string
+	Exclude []string
+}
+
+func (f *NodesFilter) Validate() error {
+	for _, pattern := range append(f.Include[:len(f.Include):len(f.Include)], f.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Wrapf(err, "NodesFilter: invalid pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+func (f *NodesFilter) Match(domain string) bool {
+	if matchAny(f.Exclude, domain) {
+		return false
+	}
+	return len(f.Include) == 0 || matchAny(f.Include, domain)
+}
+
+func (f *NodesFilter) Apply(nodes nodesWithStats) nodesWithStats {
+	if len(f.Include) == 0 && len(f.Exclude) == 0 {
+		return nodes
+	}
+	return nodes.Filter(func(node *nodeWithStats) bool {
+		return f.Match(node.NodeDomain)
+	})
+}
+
+func matchAny(patterns []string, domain string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, domain); ok { // patterns are validated, so error is ignored
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodesFilter_Validate(t *testing.T) {
+	require.NoError(t, (&NodesFilter{}).Validate())
+	require.NoError(t, (&NodesFilter{Include: []string{"*.wavesnodes.com"}, Exclude: []string{"node-?.com"}}).Validate())
+	require.Error(t, (&NodesFilter{Include: []string{"[a-"}}).Validate())
+	require.Error(t, (&NodesFilter{Exclude: []string{"[a-"}}).Validate())
+}
+
+func TestNodesFilter_Apply(t *testing.T) {
+	nodes := nodesWithStats{
+		{NodeDomain: "mainnet-aws-fr-1.wavesnodes.com"},
+		{NodeDomain: "mainnet-htz-nbg1-1.wavesnodes.com"},
+		{NodeDomain: "node.example.com"},
+		{NodeDomain: "other.org"},
+	}
+	tests := []struct {
+		filter   NodesFilter
+		expected []string
+	}{
+		{
+			filter:   NodesFilter{},
+			expected: []string{"mainnet-aws-fr-1.wavesnodes.com", "mainnet-htz-nbg1-1.wavesnodes.com", "node.example.com", "other.org"},
+		},
+		{
+			filter:   NodesFilter{Include: []string{"*.wavesnodes.com", "*.example.com"}},
+			expected: []string{"mainnet-aws-fr-1.wavesnodes.com", "mainnet-htz-nbg1-1.wavesnodes.com", "node.example.com"},
+		},
+		{
+			filter:   NodesFilter{Exclude: []string{"mainnet-aws-*"}},
+			expected: []string{"mainnet-htz-nbg1-1.wavesnodes.com", "node.example.com", "other.org"},
+		},
+		{
+			filter:   NodesFilter{Include: []string{"*.wavesnodes.com"}, Exclude: []string{"mainnet-aws-*"}},
+			expected: []string{"mainnet-htz-nbg1-1.wavesnodes.com"},
+		},
+	}
+	for i, tc := range tests {
+		require.Equal(t, tc.expected, tc.filter.Apply(nodes).Domains(), "failed testcase #%d", i)
+	}
+}
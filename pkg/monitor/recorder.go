@@ -0,0 +1,154 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/nickeskov/netmon/pkg/clock"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var ErrStatsReplayFinished = errors.New("stats replay finished")
+
+// StatsRecord is a scraped nodes statistics payload with the time when it has been received.
+type StatsRecord struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Nodes     nodesWithStats `json:"nodes"`
+}
+
+// StatsRecorder appends stats records to the underlying writer as JSON lines.
+type StatsRecorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewStatsRecorder(w io.Writer) *StatsRecorder {
+	return &StatsRecorder{enc: json.NewEncoder(w)}
+}
+
+// rawStatsRecord is StatsRecord with the stats payload as it has been received from the stats service.
+type rawStatsRecord struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Nodes     json.RawMessage `json:"nodes"`
+}
+
+// Record writes the stats payload with its timestamp. The payload is compacted to fit in a single line.
+func (r *StatsRecorder) Record(timestamp time.Time, payload json.RawMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(rawStatsRecord{Timestamp: timestamp, Nodes: payload}); err != nil {
+		return errors.Wrap(err, "failed to write stats record")
+	}
+	return nil
+}
+
+// ReadStatsRecords reads all stats records written by StatsRecorder.
+func ReadStatsRecords(r io.Reader) ([]StatsRecord, error) {
+	var records []StatsRecord
+	_, err := ScanStatsRecords(r, func(record StatsRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// ScanStatsRecords decodes stats records written by StatsRecorder one by one and passes them to fn, so records
+// aren't kept in memory. Returns the count of scanned records.
+func ScanStatsRecords(r io.Reader, fn func(record StatsRecord) error) (int, error) {
+	dec := json.NewDecoder(r)
+	for n := 0; ; n++ {
+		var record StatsRecord
+		if err := dec.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				return n, nil
+			}
+			return n, errors.Wrapf(err, "failed to read stats record #%d", n)
+		}
+		if err := fn(record); err != nil {
+			return n, err
+		}
+	}
+}
+
+// StatsReplayer is a NodesStatsScrapper which feeds recorded stats back through the monitor.
+// ScrapeNodeStats always returns the record selected by the latest Step call.
+type StatsReplayer struct {
+	mu      sync.Mutex
+	records []StatsRecord
+	current int
+}
+
+func NewStatsReplayer(records []StatsRecord) *StatsReplayer {
+	return &StatsReplayer{records: records, current: -1}
+}
+
+func (r *StatsReplayer) ScrapeNodeStats(_ context.Context) (nodesWithStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current < 0 || r.current >= len(r.records) {
+		return nil, errors.New("no stats record has been selected for replay")
+	}
+	return r.records[r.current].Nodes, nil
+}
+
+// Len returns total count of records.
+func (r *StatsReplayer) Len() int {
+	return len(r.records)
+}
+
+func (r *StatsReplayer) next() (StatsRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current+1 >= len(r.records) {
+		return StatsRecord{}, ErrStatsReplayFinished
+	}
+	r.current++
+	return r.records[r.current], nil
+}
+
+// Step selects the next record and checks nodes with the record timestamp.
+// Returns ErrStatsReplayFinished if all records have been replayed.
+func (r *StatsReplayer) Step(ctx context.Context, mon Monitor) (StatsRecord, error) {
+	record, err := r.next()
+	if err != nil {
+		return StatsRecord{}, err
+	}
+	return record, mon.CheckNodes(ctx, record.Timestamp)
+}
+
+// Replay replays all remaining records. Delays between records are the recorded ones divided by speed and
+// are measured with the given clock. If speed isn't positive, records are replayed without any delays.
+func (r *StatsReplayer) Replay(ctx context.Context, mon Monitor, clk clock.Clock, speed float64) error {
+	var prev time.Time
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		record, err := r.next()
+		if err != nil {
+			if errors.Is(err, ErrStatsReplayFinished) {
+				return nil
+			}
+			return err
+		}
+		if speed > 0 && !prev.IsZero() {
+			delay := time.Duration(float64(record.Timestamp.Sub(prev)) / speed)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-clk.After(delay):
+			}
+		}
+		prev = record.Timestamp
+		if err := mon.CheckNodes(ctx, record.Timestamp); err != nil {
+			zap.S().Errorf("failed to check nodes status for record at %s: %v", record.Timestamp, err)
+		}
+	}
+}
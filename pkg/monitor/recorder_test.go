@@ -0,0 +1,172 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nickeskov/netmon/pkg/clock"
+	"github.com/nickeskov/netmon/pkg/clock/clocktest"
+	"github.com/nickeskov/netmon/pkg/fakestats"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsRecorder_ReadStatsRecords(t *testing.T) {
+	now := time.Date(2021, 12, 2, 19, 35, 24, 0, time.UTC)
+	records := []StatsRecord{
+		{
+			Timestamp: now,
+			Nodes: nodesWithStats{
+				{NodeDomain: "a.wavesnodes.com", nodeStats: nodeStats{NetByte: MainNetSchemeChar, Height: 11, StateHash: "11"}},
+				{NodeDomain: "b.wavesnodes.com", nodeStats: nodeStats{NetByte: MainNetSchemeChar, Height: -1}},
+			},
+		},
+		{
+			Timestamp: now.Add(time.Minute),
+			Nodes: nodesWithStats{
+				{NodeDomain: "a.wavesnodes.com", nodeStats: nodeStats{NetByte: MainNetSchemeChar, Height: 12, StateHash: "12"}},
+			},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	recorder := NewStatsRecorder(buf)
+	for _, record := range records {
+		payload, err := json.Marshal(record.Nodes)
+		require.NoError(t, err)
+		require.NoError(t, recorder.Record(record.Timestamp, payload))
+	}
+
+	actual, err := ReadStatsRecords(buf)
+	require.NoError(t, err)
+	require.Len(t, actual, len(records))
+	for i := range actual {
+		sort.Slice(actual[i].Nodes, func(k, j int) bool {
+			return actual[i].Nodes[k].NodeDomain < actual[i].Nodes[j].NodeDomain
+		})
+		require.True(t, records[i].Timestamp.Equal(actual[i].Timestamp), "failed record #%d", i)
+		require.Equal(t, records[i].Nodes, actual[i].Nodes, "failed record #%d", i)
+	}
+
+	_, err = ReadStatsRecords(bytes.NewBufferString(`{"timestamp":"2021-12-02T19:35:24Z","nodes":{`))
+	require.Error(t, err)
+}
+
+func TestNetworkMonitor_CheckNodes_StatsRecorder(t *testing.T) {
+	// unknown fields must be kept in the recording, so the payload is recorded as it has been received
+	const payload = `{"a.wavesnodes.com":{"netbyte":"W","height":11,"statehash":"aa","peers":5},` +
+		`"b.wavesnodes.com":{"netbyte":"W","height":11,"statehash":"aa","peers":7}}`
+
+	srv := fakestats.NewServer()
+	defer srv.Close()
+	srv.SetRawBody([]byte(payload))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	nodes := nodesWithStats{
+		{NodeDomain: "a.wavesnodes.com", nodeStats: nodeStats{NetByte: MainNetSchemeChar, Height: 12, StateHash: "bb"}},
+	}
+	scraperMock := NewMockNodesStatsScrapper(ctrl)
+	scraperMock.EXPECT().ScrapeNodeStats(gomock.Any()).Times(1).Return(nodes, nil)
+
+	criteria := NetworkErrorCriteria{
+		NodesDown:   NodesDownCriterion{TotalDownNodesPart: 0.3},
+		NodesHeight: NodesHeightCriterion{HeightDiff: 5, RequireMinNodesOnHeight: 1},
+	}
+	// the check time is recorded instead of the clock time
+	clk := clocktest.NewFakeClock(time.Date(2021, 12, 2, 19, 40, 0, 0, time.UTC))
+	now := time.Date(2021, 12, 2, 19, 35, 24, 0, time.UTC)
+	buf := new(bytes.Buffer)
+	recorder := NewStatsRecorder(buf)
+	for i, scraper := range []NodesStatsScrapper{
+		NewNodesStatsScraperHTTP(srv.URL(), DefaultNodeStatsPollResponseSize),
+		scraperMock,
+	} {
+		mon, err := NewNetworkMonitoring(StateActive, MainNetSchemeChar, 10, scraper, 1, criteria,
+			WithClock(clk),
+			WithStatsRecorder(recorder),
+		)
+		require.NoError(t, err)
+		require.NoError(t, mon.CheckNodes(context.Background(), now.Add(time.Duration(i)*time.Minute)))
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var raw rawStatsRecord
+	require.NoError(t, json.Unmarshal(lines[0], &raw))
+	require.JSONEq(t, payload, string(raw.Nodes))
+
+	records, err := ReadStatsRecords(buf)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, now, records[0].Timestamp)
+	require.Len(t, records[0].Nodes, 2)
+	require.Equal(t, now.Add(time.Minute), records[1].Timestamp)
+	require.Equal(t, nodes, records[1].Nodes)
+}
+
+func TestStatsReplayer(t *testing.T) {
+	now := time.Now().UTC()
+	records := []StatsRecord{
+		{
+			Timestamp: now,
+			Nodes: nodesWithStats{
+				{nodeStats: nodeStats{Height: 11, StateHash: "aa", NetByte: MainNetSchemeChar}},
+				{nodeStats: nodeStats{Height: -1, NetByte: MainNetSchemeChar}},
+			},
+		},
+		{
+			Timestamp: now.Add(time.Minute),
+			Nodes: nodesWithStats{
+				{nodeStats: nodeStats{Height: 12, StateHash: "aa", NetByte: MainNetSchemeChar}},
+				{nodeStats: nodeStats{Height: 12, StateHash: "aa", NetByte: MainNetSchemeChar}},
+			},
+		},
+	}
+
+	replayer := NewStatsReplayer(records)
+	_, err := replayer.ScrapeNodeStats(context.Background())
+	require.Error(t, err)
+
+	mon, err := NewNetworkMonitoring(
+		StateActive,
+		MainNetSchemeChar,
+		10,
+		replayer,
+		1,
+		NetworkErrorCriteria{
+			NodesDown:   NodesDownCriterion{TotalDownNodesPart: 0.3},
+			NodesHeight: NodesHeightCriterion{HeightDiff: 5, RequireMinNodesOnHeight: 2},
+			StateHash: NodesStateHashCriterion{
+				MinStateHashGroupsOnSameHeight:   2,
+				MinValuableStateHashGroups:       2,
+				MinNodesInValuableStateHashGroup: 2,
+				RequireMinNodesOnHeight:          4,
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	record, err := replayer.Step(context.Background(), &mon)
+	require.NoError(t, err)
+	require.Equal(t, now, record.Timestamp)
+	require.False(t, mon.NetworkOperatesStable())
+	require.Equal(t, 11, mon.NetworkStatusInfo().Height)
+
+	require.NoError(t, replayer.Replay(context.Background(), &mon, clock.Real(), 0))
+	require.True(t, mon.NetworkOperatesStable())
+	require.Equal(t, NetworkStatusInfo{
+		Updated: now.Add(time.Minute),
+		Network: MainNetSchemeChar,
+		Status:  true,
+		Height:  12,
+		Reasons: []SeverityReason{},
+	}, mon.NetworkStatusInfo())
+
+	_, err = replayer.Step(context.Background(), &mon)
+	require.ErrorIs(t, err, ErrStatsReplayFinished)
+}
@@ -0,0 +1,152 @@
+package monitor
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultScoreMaxLag is the max height lag which isn't counted as lagging by the node scores
+// if the height lag criterion is disabled.
+const DefaultScoreMaxLag = 1
+
+// Weights of the node score components, they sum to one.
+const (
+	scoreUptimeWeight   = 0.4
+	scoreLagWeight      = 0.2
+	scoreMinorityWeight = 0.3
+	scoreFlappingWeight = 0.1
+)
+
+// NodeScore is the reliability of the node over all observed snapshots. Score is between 0 and 1, higher is better.
+// LagRate and MinorityRate are parts of the snapshots in which the node has been working, FlapRate is the count of
+// transitions between working and down states per snapshot.
+type NodeScore struct {
+	Rank         int     `json:"rank"`
+	Domain       string  `json:"domain"`
+	Score        float64 `json:"score"`
+	Samples      int     `json:"samples"`
+	Uptime       float64 `json:"uptime"`
+	LagRate      float64 `json:"lag_rate"`
+	MinorityRate float64 `json:"minority_rate"`
+	Transitions  int     `json:"transitions"`
+	FlapRate     float64 `json:"flap_rate"`
+}
+
+type NetworkNodesScoresInfo struct {
+	Updated time.Time         `json:"updated,omitempty"`
+	Network NetworkSchemeChar `json:"network"`
+	From    time.Time         `json:"from,omitempty"` // time of the first observed snapshot
+	Samples int               `json:"samples"`
+	MaxLag  int               `json:"max_lag"`
+	Nodes   []NodeScore       `json:"nodes"`
+}
+
+type nodeCounters struct {
+	samples     int
+	working     int
+	lagging     int
+	minority    int
+	transitions int
+	known       bool // working or down state has been observed
+	lastWorking bool
+}
+
+// nodeScorer accumulates per node counters. Unlike the stats history, counters aren't limited by a window.
+type nodeScorer struct {
+	maxLag   int
+	samples  int
+	from     time.Time
+	updated  time.Time
+	counters map[string]*nodeCounters
+}
+
+func newNodeScorer(criteria NetworkErrorCriteria) nodeScorer {
+	maxLag := DefaultScoreMaxLag
+	if criteria.NodesLag.Enabled() {
+		maxLag = criteria.NodesLag.MaxLag
+	}
+	return nodeScorer{maxLag: maxLag, counters: make(map[string]*nodeCounters)}
+}
+
+// observe updates counters with the snapshot of network nodes. Snapshots must be observed in chronological order.
+// Syncing and malformed states don't break the working/down sequence, see nodesTransitions.
+func (s *nodeScorer) observe(now time.Time, nodes nodesWithStats, weights map[string]float64) {
+	if len(nodes) == 0 {
+		return
+	}
+	if s.samples == 0 {
+		s.from = now
+	}
+	s.samples++
+	s.updated = now
+	maxHeight := -1
+	for _, node := range nodes.WorkingNodes() {
+		if node.Height > maxHeight {
+			maxHeight = node.Height
+		}
+	}
+	minority := minorityDomains(findForks(nodes, weights))
+	for _, node := range nodes {
+		counters, ok := s.counters[node.NodeDomain]
+		if !ok {
+			counters = &nodeCounters{}
+			s.counters[node.NodeDomain] = counters
+		}
+		counters.samples++
+		var working bool
+		switch class, _ := node.Classify(); class {
+		case NodeClassValid:
+			working = true
+			counters.working++
+			if maxHeight-node.Height > s.maxLag {
+				counters.lagging++
+			}
+			if _, ok := minority[node.NodeDomain]; ok {
+				counters.minority++
+			}
+		case NodeClassDown:
+			working = false
+		default:
+			continue
+		}
+		if counters.known && counters.lastWorking != working {
+			counters.transitions++
+		}
+		counters.known = true
+		counters.lastWorking = working
+	}
+}
+
+// scores returns node scores sorted by score in descending order.
+func (s *nodeScorer) scores() []NodeScore {
+	scores := make([]NodeScore, 0, len(s.counters))
+	for domain, counters := range s.counters {
+		score := NodeScore{
+			Domain:      domain,
+			Samples:     counters.samples,
+			Uptime:      float64(counters.working) / float64(counters.samples),
+			Transitions: counters.transitions,
+			FlapRate:    float64(counters.transitions) / float64(counters.samples),
+		}
+		if counters.working != 0 {
+			score.LagRate = float64(counters.lagging) / float64(counters.working)
+			score.MinorityRate = float64(counters.minority) / float64(counters.working)
+		}
+		// lag and minority components are weighted by uptime, so the node which is always down scores low
+		score.Score = scoreUptimeWeight*score.Uptime +
+			scoreLagWeight*score.Uptime*(1-score.LagRate) +
+			scoreMinorityWeight*score.Uptime*(1-score.MinorityRate) +
+			scoreFlappingWeight*(1-score.FlapRate)
+		scores = append(scores, score)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Domain < scores[j].Domain
+	})
+	for i := range scores {
+		scores[i].Rank = i + 1
+	}
+	return scores
+}
@@ -0,0 +1,135 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func scoresSnapshots() []nodesWithStats {
+	node := func(domain string, height int, stateHash string) nodeWithStats {
+		return nodeWithStats{
+			NodeDomain: domain,
+			nodeStats:  nodeStats{NetByte: MainNetSchemeChar, Height: height, StateHash: stateHash, StateHashHeight: height},
+		}
+	}
+	return []nodesWithStats{
+		{node("a", 10, "aa"), node("b", 10, "aa"), node("c", 10, "bb")}, // "c" is in the minority group
+		{node("a", 10, "aa"), node("b", 8, "aa"), node("c", -1, "")},    // "b" is lagging, "c" is down
+		{node("a", 11, "aa"), node("b", 0, ""), node("c", 11, "aa")},    // "b" is syncing
+	}
+}
+
+func requireScores(t *testing.T, expected, actual []NodeScore) {
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		require.InDelta(t, expected[i].Score, actual[i].Score, 1e-9, expected[i].Domain)
+		require.InDelta(t, expected[i].Uptime, actual[i].Uptime, 1e-9, expected[i].Domain)
+		require.InDelta(t, expected[i].FlapRate, actual[i].FlapRate, 1e-9, expected[i].Domain)
+		expected[i].Score, actual[i].Score = 0, 0
+		expected[i].Uptime, actual[i].Uptime = 0, 0
+		expected[i].FlapRate, actual[i].FlapRate = 0, 0
+	}
+	require.Equal(t, expected, actual)
+}
+
+func TestNodeScorer(t *testing.T) {
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	scorer := newNodeScorer(NetworkErrorCriteria{})
+	require.Empty(t, scorer.scores())
+
+	for i, nodes := range scoresSnapshots() {
+		scorer.observe(start.Add(time.Duration(i)*time.Minute), nodes, nil)
+	}
+	scorer.observe(start.Add(time.Hour), nil, nil) // empty snapshots are ignored
+
+	require.Equal(t, 3, scorer.samples)
+	require.Equal(t, start, scorer.from)
+	require.Equal(t, start.Add(2*time.Minute), scorer.updated)
+	requireScores(t, []NodeScore{
+		{Rank: 1, Domain: "a", Score: 1, Samples: 3, Uptime: 1},
+		{Rank: 2, Domain: "b", Score: 0.4*2/3 + 0.2*2/3*0.5 + 0.3*2/3 + 0.1, Samples: 3, Uptime: 2.0 / 3, LagRate: 0.5},
+		{Rank: 3, Domain: "c", Score: 0.4*2/3 + 0.2*2/3 + 0.3*2/3*0.5 + 0.1/3, Samples: 3, Uptime: 2.0 / 3,
+			MinorityRate: 0.5, Transitions: 2, FlapRate: 2.0 / 3},
+	}, scorer.scores())
+
+	// with weights "c" is the majority in the first snapshot, and lag of two blocks is tolerated by the criterion
+	scorer = newNodeScorer(NetworkErrorCriteria{NodesLag: NodesHeightLagCriterion{MaxLag: 2}})
+	for i, nodes := range scoresSnapshots() {
+		scorer.observe(start.Add(time.Duration(i)*time.Minute), nodes, map[string]float64{"c": 3})
+	}
+	scores := scorer.scores()
+	require.Equal(t, []string{"a", "c", "b"}, []string{scores[0].Domain, scores[1].Domain, scores[2].Domain})
+	require.Equal(t, 1.0/3, scores[0].MinorityRate)
+	require.Equal(t, 0.0, scores[1].MinorityRate)
+	require.Equal(t, 0.0, scores[2].LagRate)
+	require.Equal(t, 0.5, scores[2].MinorityRate)
+}
+
+func TestNodeScorer_MalformedNodes(t *testing.T) {
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	scorer := newNodeScorer(NetworkErrorCriteria{})
+	nodes := nodesWithStats{
+		{NodeDomain: "a", nodeStats: nodeStats{NetByte: MainNetSchemeChar, Height: 10, StateHash: "aa"}},
+		{NodeDomain: "m", nodeStats: nodeStats{NetByte: MainNetSchemeChar, Height: 12}}, // empty statehash
+	}
+	scorer.observe(start, nodes, nil)
+	scorer.observe(start.Add(time.Minute), nodes, nil)
+
+	// malformed node isn't working and its height doesn't make other nodes lagging
+	scores := scorer.scores()
+	require.Len(t, scores, 2)
+	require.Equal(t, "a", scores[0].Domain)
+	require.Equal(t, 1.0, scores[0].Uptime)
+	require.Equal(t, 0.0, scores[0].LagRate)
+	require.Equal(t, "m", scores[1].Domain)
+	require.Equal(t, 2, scores[1].Samples)
+	require.Equal(t, 0.0, scores[1].Uptime)
+}
+
+func TestNetworkMonitor_NetworkNodesScoresInfo(t *testing.T) {
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	records := new(bytes.Buffer)
+	recorder := NewStatsRecorder(records)
+	for i, nodes := range scoresSnapshots() {
+		other := nodeWithStats{NodeDomain: "t", nodeStats: nodeStats{NetByte: TestNetSchemeChar, Height: -1}}
+		payload, err := json.Marshal(append(nodes, other))
+		require.NoError(t, err)
+		require.NoError(t, recorder.Record(start.Add(time.Duration(i)*time.Minute), payload))
+	}
+
+	mon, err := NewNetworkMonitoring(
+		StateActive,
+		MainNetSchemeChar,
+		10,
+		nil,
+		1,
+		NetworkErrorCriteria{},
+		WithNodesFilter(NodesFilter{Exclude: []string{"b"}}),
+		WithNodesScoresRecords(records),
+	)
+	require.NoError(t, err)
+
+	info := mon.NetworkNodesScoresInfo()
+	require.Equal(t, MainNetSchemeChar, info.Network)
+	require.Equal(t, start, info.From)
+	require.Equal(t, start.Add(2*time.Minute), info.Updated)
+	require.Equal(t, 3, info.Samples)
+	require.Equal(t, DefaultScoreMaxLag, info.MaxLag)
+	require.Len(t, info.Nodes, 2)
+	require.Equal(t, "a", info.Nodes[0].Domain)
+	require.Equal(t, "c", info.Nodes[1].Domain)
+	// groups are equal when "b" is excluded, so "aa" wins by the statehash order
+	require.Equal(t, 1.0/2, info.Nodes[1].MinorityRate)
+}
+
+func TestNetworkMonitor_NodesScoresRecordsError(t *testing.T) {
+	_, err := NewNetworkMonitoring(StateActive, MainNetSchemeChar, 10, nil, 1, NetworkErrorCriteria{},
+		WithNodesScoresRecords(strings.NewReader(`{"timestamp":"2021-12-01T00:00:00Z","nodes":[]}`)),
+	)
+	require.Error(t, err)
+}
@@ -1,65 +1,275 @@
 package monitor
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
+	"time"
 
+	"github.com/nickeskov/netmon/pkg/clock"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
-const DefaultNodeStatsPollResponseSize = 128 * 1024
+const (
+	DefaultNodeStatsPollResponseSize = 128 * 1024
+	DefaultNodeStatsRequestTimeout   = 30 * time.Second
+	DefaultNodeStatsRetryBackoff     = time.Second
+	DefaultNodeStatsRetryMaxBackoff  = 10 * time.Second
+	DefaultNodeStatsUserAgent        = "netmon"
+)
 
 type NodesStatsScrapper interface {
-	ScrapeNodeStats() (nodesWithStats, error)
+	ScrapeNodeStats(ctx context.Context) (nodesWithStats, error)
+}
+
+type httpStatusError struct {
+	url        string
+	statusCode int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("failed to get nodes statuses from %q, HTTP code(%d) %q",
+		e.url,
+		e.statusCode,
+		http.StatusText(e.statusCode),
+	)
+}
+
+// ResponseTooLargeError is returned when the stats response body exceeds the configured limit.
+// The body isn't decoded in this case, because truncated JSON can't be told apart from malformed data.
+type ResponseTooLargeError struct {
+	URL   string
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response from %q exceeds limit of %d bytes", e.URL, e.Limit)
 }
 
 type nodesStatsScrapper struct {
 	nodesStatsUrl   string
 	maxResponseSize int64
+	client          *http.Client
+	requestTimeout  time.Duration
+	userAgent       string
+	retries         int
+	retryBackoff    time.Duration
+	retryMaxBackoff time.Duration
+	sizeWarnRatio   float64
+	clock           clock.Clock
 }
 
-func NewNodesStatsScraperHTTP(nodesStatsUrl string, maxResponseSize int64) NodesStatsScrapper {
-	return nodesStatsScrapper{nodesStatsUrl: nodesStatsUrl, maxResponseSize: maxResponseSize}
+type HTTPScraperOption func(s *nodesStatsScrapper)
+
+// WithHTTPClient sets the client which is used for requests, e.g. with custom proxy or TLS settings.
+func WithHTTPClient(client *http.Client) HTTPScraperOption {
+	return func(s *nodesStatsScrapper) {
+		s.client = client
+	}
+}
+
+// WithRequestTimeout sets timeout of a single request attempt. Zero value disables the timeout.
+func WithRequestTimeout(timeout time.Duration) HTTPScraperOption {
+	return func(s *nodesStatsScrapper) {
+		s.requestTimeout = timeout
+	}
 }
 
-func (s nodesStatsScrapper) ScrapeNodeStats() (nodesWithStats, error) {
-	resp, err := http.Get(s.nodesStatsUrl)
+func WithUserAgent(userAgent string) HTTPScraperOption {
+	return func(s *nodesStatsScrapper) {
+		s.userAgent = userAgent
+	}
+}
+
+// WithRetries sets count of additional attempts on network errors and HTTP 5xx responses.
+// Delays between attempts grow exponentially from backoff up to maxBackoff and are jittered.
+func WithRetries(retries int, backoff, maxBackoff time.Duration) HTTPScraperOption {
+	return func(s *nodesStatsScrapper) {
+		s.retries = retries
+		s.retryBackoff = backoff
+		s.retryMaxBackoff = maxBackoff
+	}
+}
+
+// WithScraperClock sets the clock which is used for delays between retries.
+func WithScraperClock(c clock.Clock) HTTPScraperOption {
+	return func(s *nodesStatsScrapper) {
+		s.clock = c
+	}
+}
+
+// WithResponseSizeWarnRatio enables warnings when the response size exceeds the given part of the max response size,
+// so the limit can be raised before responses become truncated. Zero value disables warnings.
+func WithResponseSizeWarnRatio(ratio float64) HTTPScraperOption {
+	return func(s *nodesStatsScrapper) {
+		s.sizeWarnRatio = ratio
+	}
+}
+
+func NewNodesStatsScraperHTTP(nodesStatsUrl string, maxResponseSize int64, opts ...HTTPScraperOption) NodesStatsScrapper {
+	s := nodesStatsScrapper{
+		nodesStatsUrl:   nodesStatsUrl,
+		maxResponseSize: maxResponseSize,
+		client:          http.DefaultClient,
+		requestTimeout:  DefaultNodeStatsRequestTimeout,
+		userAgent:       DefaultNodeStatsUserAgent,
+		retryBackoff:    DefaultNodeStatsRetryBackoff,
+		retryMaxBackoff: DefaultNodeStatsRetryMaxBackoff,
+		clock:           clock.Real(),
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// rawNodesStatsScrapper is a NodesStatsScrapper which also returns the raw stats payload.
+type rawNodesStatsScrapper interface {
+	NodesStatsScrapper
+	ScrapeRawNodeStats(ctx context.Context) (nodesWithStats, json.RawMessage, error)
+}
+
+func (s nodesStatsScrapper) ScrapeNodeStats(ctx context.Context) (nodesWithStats, error) {
+	allNodes, _, err := s.ScrapeRawNodeStats(ctx)
+	return allNodes, err
+}
+
+func (s nodesStatsScrapper) ScrapeRawNodeStats(ctx context.Context) (nodesWithStats, json.RawMessage, error) {
+	attempts := s.retries + 1
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			case <-s.clock.After(s.backoff(attempt - 1)):
+			}
+		}
+		var (
+			allNodes nodesWithStats
+			payload  json.RawMessage
+		)
+		allNodes, payload, err = s.scrapeNodeStatsAttempt(ctx)
+		if err == nil {
+			zap.S().Debugw("stats have been scraped",
+				"url", s.nodesStatsUrl, "attempt", attempt, "attempts", attempts, "nodes", len(allNodes),
+			)
+			return allNodes, payload, nil
+		}
+		zap.S().Warnw("failed to scrape stats",
+			"url", s.nodesStatsUrl, "attempt", attempt, "attempts", attempts, zap.Error(err),
+		)
+		if !isRetryableScrapeError(ctx, err) {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, errors.Wrapf(err, "all %d attempts to get stats have failed", attempts)
+}
+
+// backoff returns jittered delay before the given retry.
+func (s nodesStatsScrapper) backoff(retry int) time.Duration {
+	delay := s.retryBackoff
+	for i := 1; i < retry && delay < s.retryMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.retryMaxBackoff {
+		delay = s.retryMaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// "equal jitter": half of the delay is fixed and the other half is random
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+func isRetryableScrapeError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false // parent context is done
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.statusCode >= http.StatusInternalServerError
+	}
+	var tooLargeErr *ResponseTooLargeError
+	if errors.As(err, &tooLargeErr) {
+		return false
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return false
+	}
+	return true // network errors
+}
+
+func (s nodesStatsScrapper) scrapeNodeStatsAttempt(ctx context.Context) (nodesWithStats, json.RawMessage, error) {
+	if s.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.requestTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.nodesStatsUrl, nil)
 	if err != nil {
-		return nodesWithStats{}, err
+		return nil, nil, err
+	}
+	if s.userAgent != "" {
+		req.Header.Set("User-Agent", s.userAgent)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, nil, err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
 			zap.S().Errorf("failed to close response body: %v", err)
 		}
 	}()
-	responseBody := io.LimitReader(resp.Body, s.maxResponseSize)
-
 	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(responseBody)
+		body, err := io.ReadAll(io.LimitReader(resp.Body, s.maxResponseSize))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		zap.S().Errorf("stats sevice returned response with HTTP code %d %q, response is %q",
-			resp.StatusCode,
-			http.StatusText(resp.StatusCode),
-			string(body),
+		zap.S().Errorw("stats service returned unexpected HTTP status",
+			"url", s.nodesStatsUrl,
+			"status_code", resp.StatusCode,
+			"response", string(body),
 		)
-		return nodesWithStats{},
-			errors.Errorf("failed to get nodes statuses from %q, HTTP code(%d) %q",
-				s.nodesStatsUrl,
-				resp.StatusCode,
-				http.StatusText(resp.StatusCode),
-			)
+		return nil, nil, &httpStatusError{url: s.nodesStatsUrl, statusCode: resp.StatusCode}
 	}
 
-	allNodes := nodesWithStats{}
-	if err := json.NewDecoder(responseBody).Decode(&allNodes); err != nil {
-		return nodesWithStats{}, err
+	// read one extra byte to detect that the body exceeds the limit
+	body, err := io.ReadAll(io.LimitReader(resp.Body, s.maxResponseSize+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.checkResponseSize(int64(len(body))); err != nil {
+		return nil, nil, err
 	}
 
-	zap.S().Debugf("stats successfully received from %q", s.nodesStatsUrl)
+	allNodes := nodesWithStats{}
+	if err := json.Unmarshal(body, &allNodes); err != nil {
+		return nil, nil, err
+	}
+	return allNodes, body, nil
+}
 
-	return allNodes, nil
+func (s nodesStatsScrapper) checkResponseSize(size int64) error {
+	metricScrapeResponseSizeLimitBytes.Set(s.maxResponseSize)
+	if size > s.maxResponseSize {
+		metricScrapeOversizedResponsesTotal.Add(1)
+		return &ResponseTooLargeError{URL: s.nodesStatsUrl, Limit: s.maxResponseSize}
+	}
+	metricScrapeResponseSizeBytes.Set(size)
+	if s.sizeWarnRatio > 0 && float64(size) >= s.sizeWarnRatio*float64(s.maxResponseSize) {
+		metricScrapeNearLimitResponsesTotal.Add(1)
+		zap.S().Warnw("stats response size is close to the limit",
+			"url", s.nodesStatsUrl, "size", size, "limit", s.maxResponseSize,
+		)
+	}
+	return nil
 }
@@ -5,6 +5,7 @@
 package monitor
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,16 +35,16 @@ func (m *MockNodesStatsScrapper) EXPECT() *MockNodesStatsScrapperMockRecorder {
 }
 
 // ScrapeNodeStats mocks base method.
-func (m *MockNodesStatsScrapper) ScrapeNodeStats() (nodesWithStats, error) {
+func (m *MockNodesStatsScrapper) ScrapeNodeStats(ctx context.Context) (nodesWithStats, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ScrapeNodeStats")
+	ret := m.ctrl.Call(m, "ScrapeNodeStats", ctx)
 	ret0, _ := ret[0].(nodesWithStats)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ScrapeNodeStats indicates an expected call of ScrapeNodeStats.
-func (mr *MockNodesStatsScrapperMockRecorder) ScrapeNodeStats() *gomock.Call {
+func (mr *MockNodesStatsScrapperMockRecorder) ScrapeNodeStats(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScrapeNodeStats", reflect.TypeOf((*MockNodesStatsScrapper)(nil).ScrapeNodeStats))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScrapeNodeStats", reflect.TypeOf((*MockNodesStatsScrapper)(nil).ScrapeNodeStats), ctx)
 }
@@ -1,11 +1,15 @@
 package monitor
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"sort"
 	"testing"
+	"time"
 
+	"github.com/nickeskov/netmon/pkg/clock/clocktest"
+	"github.com/nickeskov/netmon/pkg/fakestats"
 	"github.com/stretchr/testify/require"
 )
 
@@ -80,7 +84,7 @@ func TestNodesStatsScrapper_ScrapeNodeStats(t *testing.T) {
 	defer srv.Close()
 
 	scraper := NewNodesStatsScraperHTTP(srv.URL, DefaultNodeStatsPollResponseSize)
-	actual, err := scraper.ScrapeNodeStats()
+	actual, err := scraper.ScrapeNodeStats(context.Background())
 	require.NoError(t, err)
 	sort.Slice(actual, func(i, j int) bool {
 		return actual[i].NodeDomain < actual[j].NodeDomain
@@ -90,28 +94,154 @@ func TestNodesStatsScrapper_ScrapeNodeStats(t *testing.T) {
 }
 
 func TestNodesStatsScrapper_ScrapeNodeStats_InvalidJSON(t *testing.T) {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		_, err := w.Write([]byte("{corruptedData"))
-		require.NoError(t, err)
-	})
-	srv := httptest.NewServer(mux)
+	srv := fakestats.NewServer()
 	defer srv.Close()
+	srv.SetRawBody([]byte("{corruptedData"))
 
-	scraper := NewNodesStatsScraperHTTP(srv.URL, DefaultNodeStatsPollResponseSize)
-	_, err := scraper.ScrapeNodeStats()
+	scraper := NewNodesStatsScraperHTTP(srv.URL(), DefaultNodeStatsPollResponseSize)
+	_, err := scraper.ScrapeNodeStats(context.Background())
 	require.Error(t, err)
 }
 
 func TestNodesStatsScrapper_ScrapeNodeStats_InvalidHTTPCode(t *testing.T) {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-	})
-	srv := httptest.NewServer(mux)
+	srv := fakestats.NewServer()
 	defer srv.Close()
+	srv.FailWithStatus(http.StatusBadRequest, 1)
 
-	scraper := NewNodesStatsScraperHTTP(srv.URL, DefaultNodeStatsPollResponseSize)
-	_, err := scraper.ScrapeNodeStats()
+	scraper := NewNodesStatsScraperHTTP(srv.URL(), DefaultNodeStatsPollResponseSize)
+	_, err := scraper.ScrapeNodeStats(context.Background())
 	require.Error(t, err)
 }
+
+func TestNodesStatsScrapper_ScrapeNodeStats_Retries(t *testing.T) {
+	srv := fakestats.NewServer()
+	defer srv.Close()
+	srv.AddNodes(string(MainNetSchemeChar), 100, "aa", "n1", "n2")
+
+	clk := clocktest.NewFakeClock(time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC))
+	scraper := NewNodesStatsScraperHTTP(srv.URL(), DefaultNodeStatsPollResponseSize,
+		WithRetries(2, time.Minute, 2*time.Minute),
+		WithUserAgent("netmon-test"),
+		WithScraperClock(clk),
+	)
+	// scrape advances the fake clock for each retry, so backoff doesn't sleep
+	scrape := func(retries int) (nodesWithStats, error) {
+		type result struct {
+			nodes nodesWithStats
+			err   error
+		}
+		done := make(chan result, 1)
+		go func() {
+			nodes, err := scraper.ScrapeNodeStats(context.Background())
+			done <- result{nodes, err}
+		}()
+		for i := 0; i < retries; i++ {
+			clk.BlockUntilWaiters(1)
+			clk.Advance(2 * time.Minute)
+		}
+		res := <-done
+		return res.nodes, res.err
+	}
+
+	// 5xx is retried
+	srv.FailWithStatus(http.StatusBadGateway, 2)
+	nodes, err := scrape(2)
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+	require.Equal(t, 3, srv.Requests())
+	require.Equal(t, "netmon-test", srv.LastRequestHeader().Get("User-Agent"))
+
+	// all attempts are failed
+	srv.FailWithStatus(http.StatusServiceUnavailable, 3)
+	_, err = scrape(2)
+	require.Error(t, err)
+	require.Equal(t, 6, srv.Requests())
+
+	// 4xx isn't retried
+	srv.FailWithStatus(http.StatusNotFound, 1)
+	_, err = scraper.ScrapeNodeStats(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 7, srv.Requests())
+
+	// invalid JSON isn't retried
+	srv.Reset()
+	srv.SetRawBody([]byte("{corruptedData"))
+	_, err = scraper.ScrapeNodeStats(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 8, srv.Requests())
+}
+
+func TestNodesStatsScrapper_ScrapeNodeStats_Timeout(t *testing.T) {
+	srv := fakestats.NewServer()
+	defer srv.Close()
+	srv.AddNodes(string(MainNetSchemeChar), 100, "aa", "n1")
+	srv.SetResponseDelay(time.Second)
+
+	scraper := NewNodesStatsScraperHTTP(srv.URL(), DefaultNodeStatsPollResponseSize,
+		WithRequestTimeout(10*time.Millisecond),
+		WithRetries(1, time.Millisecond, time.Millisecond),
+	)
+	_, err := scraper.ScrapeNodeStats(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 2, srv.Requests())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = scraper.ScrapeNodeStats(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 2, srv.Requests())
+}
+
+func TestNodesStatsScrapper_backoff(t *testing.T) {
+	s := nodesStatsScrapper{retryBackoff: 100 * time.Millisecond, retryMaxBackoff: 300 * time.Millisecond}
+	tests := []struct {
+		retry    int
+		min, max time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 150 * time.Millisecond, 300 * time.Millisecond},
+		{10, 150 * time.Millisecond, 300 * time.Millisecond},
+	}
+	for _, tc := range tests {
+		for i := 0; i < 10; i++ {
+			delay := s.backoff(tc.retry)
+			require.GreaterOrEqual(t, delay, tc.min, "failed retry %d", tc.retry)
+			require.LessOrEqual(t, delay, tc.max, "failed retry %d", tc.retry)
+		}
+	}
+}
+
+func TestNodesStatsScrapper_ScrapeNodeStats_ResponseTooLarge(t *testing.T) {
+	srv := fakestats.NewServer()
+	defer srv.Close()
+	srv.AddNodes(string(MainNetSchemeChar), 100, "aa", "n1")
+
+	const limit = 1024
+	scraper := NewNodesStatsScraperHTTP(srv.URL(), limit,
+		WithRetries(2, time.Millisecond, time.Millisecond),
+		WithResponseSizeWarnRatio(0.5),
+	)
+
+	oversized := metricScrapeOversizedResponsesTotal.Value()
+	srv.SetOversizedBody(limit + 1)
+	_, err := scraper.ScrapeNodeStats(context.Background())
+	var tooLargeErr *ResponseTooLargeError
+	require.ErrorAs(t, err, &tooLargeErr)
+	require.Equal(t, int64(limit), tooLargeErr.Limit)
+	require.Equal(t, 1, srv.Requests()) // isn't retried
+	require.Equal(t, oversized+1, metricScrapeOversizedResponsesTotal.Value())
+
+	nearLimit := metricScrapeNearLimitResponsesTotal.Value()
+	srv.SetOversizedBody(limit)
+	nodes, err := scraper.ScrapeNodeStats(context.Background())
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	require.Equal(t, int64(limit), metricScrapeResponseSizeBytes.Value())
+	require.Equal(t, nearLimit+1, metricScrapeNearLimitResponsesTotal.Value())
+
+	srv.Reset()
+	_, err = scraper.ScrapeNodeStats(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, nearLimit+1, metricScrapeNearLimitResponsesTotal.Value())
+}
@@ -0,0 +1,24 @@
+package monitor
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// seconds is the duration which is encoded in JSON as a number of seconds. Durations of API responses are encoded
+// this way, see MarshalJSON methods of the response types.
+type seconds time.Duration
+
+func (s seconds) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(s).Seconds())
+}
+
+func (s *seconds) UnmarshalJSON(data []byte) error {
+	var v float64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = seconds(math.Round(v * float64(time.Second)))
+	return nil
+}
@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecondsJSON(t *testing.T) {
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    any
+		decoded  any
+		contains []string
+	}{
+		{
+			value:    LoopInfo{PollInterval: time.Minute, State: "active"},
+			decoded:  &LoopInfo{},
+			contains: []string{`"poll_interval":60`},
+		},
+		{
+			value: Fork{Height: 10, Groups: []ForkGroup{}, MinorityNodes: []ForkNode{}, Since: start,
+				Duration: 1500 * time.Millisecond,
+			},
+			decoded:  &Fork{},
+			contains: []string{`"duration":1.5`, `"height":10`},
+		},
+		{
+			value: Incident{ID: 1, Start: start, Duration: 6 * time.Minute, Criteria: []string{}, Nodes: []string{},
+				StateChanges: []IncidentStateChange{}, Notes: []IncidentNote{},
+			},
+			decoded:  &Incident{},
+			contains: []string{`"duration":360`, `"id":1`},
+		},
+		{
+			value: NetworkChainInfo{Height: 10, ExpectedBlockInterval: time.Minute, Rates: []BlockRate{
+				{Window: 5 * time.Minute, Span: 4 * time.Minute, Blocks: 4, BlocksPerMinute: 1, AvgBlockInterval: time.Minute},
+			}},
+			decoded:  &NetworkChainInfo{},
+			contains: []string{`"expected_block_interval":60`, `"window":300`, `"span":240`, `"avg_block_interval":60`},
+		},
+		{
+			value: SLAReport{From: start, To: start.Add(time.Hour), Operational: 50 * time.Minute, Degraded: 10 * time.Minute,
+				MTTR: 10 * time.Minute, LongestOutage: 10 * time.Minute, IncidentCount: 1,
+				Incidents: []SLAIncident{{Start: start, End: start.Add(10 * time.Minute), Duration: 10 * time.Minute}},
+			},
+			decoded: &SLAReport{},
+			contains: []string{`"operational":3000`, `"degraded":600`, `"frozen":0`, `"unknown":0`, `"mttr":600`,
+				`"longest_outage":600`, `"duration":600`, `"incident_count":1`,
+			},
+		},
+	}
+	for _, tc := range tests {
+		data, err := json.Marshal(tc.value)
+		require.NoError(t, err)
+		for _, s := range tc.contains {
+			require.Contains(t, string(data), s)
+		}
+		require.NoError(t, json.Unmarshal(data, tc.decoded))
+		require.Equal(t, tc.value, reflect.ValueOf(tc.decoded).Elem().Interface())
+	}
+}
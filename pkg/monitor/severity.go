@@ -0,0 +1,173 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Names of the network error criteria which are used in incidents and severity rules.
+const (
+	CriterionNodesDown     = "nodes_down"
+	CriterionNodesSyncing  = "nodes_syncing"
+	CriterionNodesHeight   = "nodes_height"
+	CriterionNodesLag      = "nodes_lag"
+	CriterionStateHash     = "statehash"
+	CriterionRequiredNodes = "required_nodes"
+	CriterionNodesFlapping = "nodes_flapping"
+	CriterionBlockInterval = "block_interval"
+	CriterionAnomaly       = "anomaly"
+)
+
+const (
+	SeverityOK Severity = iota
+	SeverityWarning
+	SeverityDegraded
+	SeverityCritical
+)
+
+// Severity is the graded network status, greater value is more severe.
+type Severity int
+
+func NewSeverityFromString(severity string) (Severity, error) {
+	switch severity {
+	case "ok":
+		return SeverityOK, nil
+	case "warning":
+		return SeverityWarning, nil
+	case "degraded":
+		return SeverityDegraded, nil
+	case "critical":
+		return SeverityCritical, nil
+	default:
+		return 0, errors.Errorf("failed parse severity from string, invalid severity string %q", severity)
+	}
+}
+
+func (s Severity) Validate() error {
+	switch s {
+	case SeverityOK, SeverityWarning, SeverityDegraded, SeverityCritical:
+		return nil
+	default:
+		return errors.Errorf("invalid severity (%d)", s)
+	}
+}
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityOK:
+		return "ok"
+	case SeverityWarning:
+		return "warning"
+	case SeverityDegraded:
+		return "degraded"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return fmt.Sprintf("unknown severity (%d)", s)
+	}
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	severity, err := NewSeverityFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*s = severity
+	return nil
+}
+
+// SeverityRule sets the severity of the alerted criterion. If EscalateAfter isn't zero and the criterion has been
+// alerted continuously for at least that duration, the severity is escalated to EscalateTo.
+type SeverityRule struct {
+	Severity      Severity
+	EscalateAfter time.Duration
+	EscalateTo    Severity
+}
+
+func (r *SeverityRule) Validate() error {
+	if err := r.Severity.Validate(); err != nil {
+		return err
+	}
+	if r.EscalateAfter < 0 {
+		return errors.Errorf("SeverityRule.EscalateAfter value should be non negative")
+	}
+	if r.EscalateAfter == 0 {
+		return nil
+	}
+	if err := r.EscalateTo.Validate(); err != nil {
+		return err
+	}
+	if r.EscalateTo < r.Severity {
+		return errors.Errorf("SeverityRule.EscalateTo value should be not less than SeverityRule.Severity")
+	}
+	return nil
+}
+
+func (r *SeverityRule) severity(alerted time.Duration) Severity {
+	if r.EscalateAfter != 0 && alerted >= r.EscalateAfter {
+		return r.EscalateTo
+	}
+	return r.Severity
+}
+
+// SeverityRules maps criteria names to their severity rules. Criteria which aren't in the map are degraded.
+type SeverityRules map[string]SeverityRule
+
+// DefaultSeverityRules returns rules which are used for criteria without configured rules,
+// e.g. a statehash fork is critical while lagging nodes are a warning.
+func DefaultSeverityRules() SeverityRules {
+	return SeverityRules{
+		CriterionNodesDown:     {Severity: SeverityDegraded},
+		CriterionNodesSyncing:  {Severity: SeverityWarning},
+		CriterionNodesHeight:   {Severity: SeverityDegraded},
+		CriterionNodesLag:      {Severity: SeverityWarning},
+		CriterionStateHash:     {Severity: SeverityCritical},
+		CriterionRequiredNodes: {Severity: SeverityCritical},
+		CriterionNodesFlapping: {Severity: SeverityWarning},
+		CriterionBlockInterval: {Severity: SeverityDegraded},
+		CriterionAnomaly:       {Severity: SeverityWarning},
+	}
+}
+
+func (r SeverityRules) Validate() error {
+	for criterion, rule := range r {
+		if _, ok := DefaultSeverityRules()[criterion]; !ok {
+			return errors.Errorf("unknown criterion %q in severity rules", criterion)
+		}
+		if err := rule.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid severity rule of criterion %q", criterion)
+		}
+	}
+	return nil
+}
+
+// severity returns severity of the criterion which has been alerted continuously for the given duration.
+func (r SeverityRules) severity(criterion string, alerted time.Duration) Severity {
+	rule, ok := r[criterion]
+	if !ok {
+		return SeverityDegraded
+	}
+	return rule.severity(alerted)
+}
+
+// criteriaSeverity returns the max severity of the criteria which have just been alerted.
+func (r SeverityRules) criteriaSeverity(criteria []string) Severity {
+	severity := SeverityOK
+	for _, criterion := range criteria {
+		severity = max(severity, r.severity(criterion, 0))
+	}
+	return severity
+}
+
+// SeverityReason is the alerted criterion or the frozen monitor state which defines the network severity.
+type SeverityReason struct {
+	Reason   string    `json:"reason"`
+	Severity Severity  `json:"severity"`
+	Since    time.Time `json:"since"`
+}
@@ -0,0 +1,167 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nickeskov/netmon/pkg/clock/clocktest"
+	"github.com/nickeskov/netmon/pkg/fakestats"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeverity_Text(t *testing.T) {
+	for _, severity := range []Severity{SeverityOK, SeverityWarning, SeverityDegraded, SeverityCritical} {
+		text, err := severity.MarshalText()
+		require.NoError(t, err)
+		var actual Severity
+		require.NoError(t, actual.UnmarshalText(text))
+		require.Equal(t, severity, actual)
+	}
+	var severity Severity
+	require.Error(t, severity.UnmarshalText([]byte("fatal")))
+}
+
+func TestSeverityRules_Validate(t *testing.T) {
+	require.NoError(t, DefaultSeverityRules().Validate())
+	require.NoError(t, SeverityRules{
+		CriterionNodesDown: {Severity: SeverityWarning, EscalateAfter: time.Minute, EscalateTo: SeverityCritical},
+	}.Validate())
+
+	for _, rules := range []SeverityRules{
+		{"unknown": {Severity: SeverityWarning}},
+		{CriterionNodesDown: {Severity: Severity(10)}},
+		{CriterionNodesDown: {Severity: SeverityWarning, EscalateAfter: -time.Minute}},
+		{CriterionNodesDown: {Severity: SeverityCritical, EscalateAfter: time.Minute, EscalateTo: SeverityWarning}},
+	} {
+		require.Error(t, rules.Validate(), "%+v", rules)
+	}
+}
+
+func TestSeverityRules_Severity(t *testing.T) {
+	rules := SeverityRules{
+		CriterionNodesDown: {Severity: SeverityWarning, EscalateAfter: 5 * time.Minute, EscalateTo: SeverityCritical},
+		CriterionStateHash: {Severity: SeverityCritical},
+	}
+	require.Equal(t, SeverityWarning, rules.severity(CriterionNodesDown, 0))
+	require.Equal(t, SeverityWarning, rules.severity(CriterionNodesDown, 4*time.Minute))
+	require.Equal(t, SeverityCritical, rules.severity(CriterionNodesDown, 5*time.Minute))
+	require.Equal(t, SeverityCritical, rules.severity(CriterionStateHash, time.Hour))
+	require.Equal(t, SeverityDegraded, rules.severity(CriterionAnomaly, 0)) // rule isn't set
+	require.Equal(t, SeverityCritical, rules.criteriaSeverity([]string{CriterionNodesDown, CriterionStateHash}))
+	require.Equal(t, SeverityOK, rules.criteriaSeverity(nil))
+}
+
+func TestNetworkMonitor_Severity(t *testing.T) {
+	srv := fakestats.NewServer()
+	defer srv.Close()
+	srv.AddNodes(string(MainNetSchemeChar), 100, "aa", "n1", "n2", "n3", "n4")
+
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	clk := clocktest.NewFakeClock(start)
+	mon, err := NewNetworkMonitoring(
+		StateActive,
+		MainNetSchemeChar,
+		10,
+		NewNodesStatsScraperHTTP(srv.URL(), DefaultNodeStatsPollResponseSize),
+		1,
+		NetworkErrorCriteria{
+			NodesDown:   NodesDownCriterion{TotalDownNodesPart: 0.2},
+			NodesHeight: NodesHeightCriterion{HeightDiff: 5, RequireMinNodesOnHeight: 2},
+			StateHash: NodesStateHashCriterion{
+				MinStateHashGroupsOnSameHeight:   2,
+				MinValuableStateHashGroups:       2,
+				MinNodesInValuableStateHashGroup: 2,
+				RequireMinNodesOnHeight:          4,
+			},
+		},
+		WithClock(clk),
+		WithSeverityRules(SeverityRules{
+			CriterionNodesDown: {Severity: SeverityWarning, EscalateAfter: 2 * time.Minute, EscalateTo: SeverityCritical},
+		}),
+	)
+	require.NoError(t, err)
+
+	check := func() NetworkStatusInfo {
+		require.NoError(t, mon.CheckNodes(context.Background(), clk.Now()))
+		clk.Advance(time.Minute)
+		return mon.NetworkStatusInfo()
+	}
+	info := check()
+	require.Equal(t, SeverityOK, info.Severity)
+	require.Empty(t, info.Reasons)
+
+	srv.NodesDown("n1")
+	downReason := SeverityReason{Reason: CriterionNodesDown, Severity: SeverityWarning, Since: start.Add(time.Minute)}
+	for i := 0; i < 2; i++ {
+		info = check()
+		require.False(t, info.Status) // legacy status doesn't depend on severity
+		require.Equal(t, SeverityWarning, info.Severity)
+		require.Equal(t, []SeverityReason{downReason}, info.Reasons)
+	}
+	info = check() // escalated after two minutes
+	downReason.Severity = SeverityCritical
+	require.Equal(t, SeverityCritical, info.Severity)
+	require.Equal(t, []SeverityReason{downReason}, info.Reasons)
+
+	mon.ChangeState(StateFrozenNetworkDegraded)
+	info = mon.NetworkStatusInfo()
+	require.Equal(t, SeverityDegraded, info.Severity)
+	require.Equal(t, []SeverityReason{
+		{Reason: StateFrozenNetworkDegraded.String(), Severity: SeverityDegraded, Since: start.Add(4 * time.Minute)},
+	}, info.Reasons)
+
+	mon.ChangeState(StateActive) // alerted criteria are reset
+	info = check()
+	downReason = SeverityReason{Reason: CriterionNodesDown, Severity: SeverityWarning, Since: start.Add(4 * time.Minute)}
+	require.Equal(t, []SeverityReason{downReason}, info.Reasons)
+
+	_, err = NewNetworkMonitoring(StateActive, MainNetSchemeChar, 10, nil, 1, NetworkErrorCriteria{},
+		WithSeverityRules(SeverityRules{"unknown": {}}),
+	)
+	require.Error(t, err)
+}
+
+func TestNetworkMonitor_SeverityErrorStreak(t *testing.T) {
+	srv := fakestats.NewServer()
+	defer srv.Close()
+	srv.AddNodes(string(MainNetSchemeChar), 100, "aa", "n1", "n2", "n3", "n4")
+
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	clk := clocktest.NewFakeClock(start)
+	mon, err := NewNetworkMonitoring(
+		StateActive,
+		MainNetSchemeChar,
+		10,
+		NewNodesStatsScraperHTTP(srv.URL(), DefaultNodeStatsPollResponseSize),
+		2,
+		NetworkErrorCriteria{
+			NodesDown:   NodesDownCriterion{TotalDownNodesPart: 0.2},
+			NodesHeight: NodesHeightCriterion{HeightDiff: 5, RequireMinNodesOnHeight: 2},
+			StateHash: NodesStateHashCriterion{
+				MinStateHashGroupsOnSameHeight:   2,
+				MinValuableStateHashGroups:       2,
+				MinNodesInValuableStateHashGroup: 2,
+				RequireMinNodesOnHeight:          4,
+			},
+		},
+		WithClock(clk),
+	)
+	require.NoError(t, err)
+
+	check := func() NetworkStatusInfo {
+		require.NoError(t, mon.CheckNodes(context.Background(), clk.Now()))
+		clk.Advance(time.Minute)
+		return mon.NetworkStatusInfo()
+	}
+	srv.Fork("bb", "n3", "n4")
+	info := check() // the error streak hasn't been reached, critical criterion is capped
+	require.True(t, info.Status)
+	require.Equal(t, SeverityWarning, info.Severity)
+	require.Equal(t, []SeverityReason{{Reason: CriterionStateHash, Severity: SeverityWarning, Since: start}}, info.Reasons)
+
+	info = check()
+	require.False(t, info.Status)
+	require.Equal(t, SeverityCritical, info.Severity)
+	require.Equal(t, []SeverityReason{{Reason: CriterionStateHash, Severity: SeverityCritical, Since: start}}, info.Reasons)
+}
@@ -0,0 +1,157 @@
+package monitor
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SLAIncident is a period of the degraded network status within the SLA report period.
+// Ongoing is true if the network hasn't recovered till the end of the period.
+type SLAIncident struct {
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Duration time.Duration `json:"duration"`
+	Ongoing  bool          `json:"ongoing"`
+}
+
+// MarshalJSON encodes Duration in seconds.
+func (i SLAIncident) MarshalJSON() ([]byte, error) {
+	type slaIncident SLAIncident
+	return json.Marshal(struct {
+		slaIncident
+		Duration seconds `json:"duration"`
+	}{slaIncident(i), seconds(i.Duration)})
+}
+
+func (i *SLAIncident) UnmarshalJSON(data []byte) error {
+	type slaIncident SLAIncident
+	aux := struct {
+		*slaIncident
+		Duration seconds `json:"duration"`
+	}{slaIncident: (*slaIncident)(i)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	i.Duration = time.Duration(aux.Duration)
+	return nil
+}
+
+// SLAReport is the network availability over the period. Frozen and unknown (e.g. before the first transition or
+// while netmon has been stopped) intervals are reported separately and are excluded from the uptime calculation.
+// UptimePercent is zero if there are neither operational nor degraded intervals.
+type SLAReport struct {
+	Network       NetworkSchemeChar `json:"network"`
+	From          time.Time         `json:"from"`
+	To            time.Time         `json:"to"`
+	UptimePercent float64           `json:"uptime_percent"`
+	Operational   time.Duration     `json:"operational"`
+	Degraded      time.Duration     `json:"degraded"`
+	Frozen        time.Duration     `json:"frozen"`
+	Unknown       time.Duration     `json:"unknown"`
+	IncidentCount int               `json:"incident_count"`
+	MTTR          time.Duration     `json:"mttr"` // mean time to recovery of the recovered incidents
+	LongestOutage time.Duration     `json:"longest_outage"`
+	Incidents     []SLAIncident     `json:"incidents"`
+}
+
+// MarshalJSON encodes durations in seconds.
+func (r SLAReport) MarshalJSON() ([]byte, error) {
+	type slaReport SLAReport
+	return json.Marshal(struct {
+		slaReport
+		Operational   seconds `json:"operational"`
+		Degraded      seconds `json:"degraded"`
+		Frozen        seconds `json:"frozen"`
+		Unknown       seconds `json:"unknown"`
+		MTTR          seconds `json:"mttr"`
+		LongestOutage seconds `json:"longest_outage"`
+	}{
+		slaReport:     slaReport(r),
+		Operational:   seconds(r.Operational),
+		Degraded:      seconds(r.Degraded),
+		Frozen:        seconds(r.Frozen),
+		Unknown:       seconds(r.Unknown),
+		MTTR:          seconds(r.MTTR),
+		LongestOutage: seconds(r.LongestOutage),
+	})
+}
+
+func (r *SLAReport) UnmarshalJSON(data []byte) error {
+	type slaReport SLAReport
+	aux := struct {
+		*slaReport
+		Operational   seconds `json:"operational"`
+		Degraded      seconds `json:"degraded"`
+		Frozen        seconds `json:"frozen"`
+		Unknown       seconds `json:"unknown"`
+		MTTR          seconds `json:"mttr"`
+		LongestOutage seconds `json:"longest_outage"`
+	}{slaReport: (*slaReport)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Operational, r.Degraded = time.Duration(aux.Operational), time.Duration(aux.Degraded)
+	r.Frozen, r.Unknown = time.Duration(aux.Frozen), time.Duration(aux.Unknown)
+	r.MTTR, r.LongestOutage = time.Duration(aux.MTTR), time.Duration(aux.LongestOutage)
+	return nil
+}
+
+// ComputeSLA calculates SLA report over [from, to) period. Transitions must be ordered by time, the last transition
+// before the period defines the status at the beginning of the period. The latest status lasts till the period end.
+func ComputeSLA(transitions []StatusTransition, from, to time.Time) (SLAReport, error) {
+	if !from.Before(to) {
+		return SLAReport{}, errors.Errorf("invalid SLA period from %s to %s", from, to)
+	}
+	report := SLAReport{From: from, To: to, Incidents: []SLAIncident{}}
+	var recoveredTotal time.Duration
+	recoveredCnt := 0
+	for i, transition := range transitions {
+		start := transition.Timestamp
+		end := to
+		if i+1 < len(transitions) {
+			end = transitions[i+1].Timestamp
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !start.Before(end) {
+			continue
+		}
+		duration := end.Sub(start)
+		switch transition.Status {
+		case NetworkStatusOperational:
+			report.Operational += duration
+		case NetworkStatusFrozen:
+			report.Frozen += duration
+		case NetworkStatusUnknown:
+			// unknown intervals are the rest of the period, see below
+		case NetworkStatusDegraded:
+			report.Degraded += duration
+			incident := SLAIncident{Start: start, End: end, Duration: duration, Ongoing: i+1 == len(transitions)}
+			report.Incidents = append(report.Incidents, incident)
+			if !incident.Ongoing {
+				recoveredTotal += duration
+				recoveredCnt++
+			}
+			if duration > report.LongestOutage {
+				report.LongestOutage = duration
+			}
+		default:
+			return SLAReport{}, errors.Errorf("unknown network status %q at %s", transition.Status, transition.Timestamp)
+		}
+	}
+	report.Unknown = to.Sub(from) - report.Operational - report.Degraded - report.Frozen
+	report.IncidentCount = len(report.Incidents)
+	if recoveredCnt != 0 {
+		report.MTTR = recoveredTotal / time.Duration(recoveredCnt)
+	}
+	if monitored := report.Operational + report.Degraded; monitored > 0 {
+		report.UptimePercent = 100 * float64(report.Operational) / float64(monitored)
+	}
+	return report, nil
+}
@@ -0,0 +1,128 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nickeskov/netmon/pkg/clock/clocktest"
+	"github.com/nickeskov/netmon/pkg/fakestats"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeSLA(t *testing.T) {
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	transitions := []StatusTransition{
+		{Timestamp: start.Add(-time.Hour), Status: NetworkStatusOperational},
+		{Timestamp: start.Add(10 * time.Hour), Status: NetworkStatusDegraded},
+		{Timestamp: start.Add(11 * time.Hour), Status: NetworkStatusOperational},
+		{Timestamp: start.Add(20 * time.Hour), Status: NetworkStatusFrozen},
+		{Timestamp: start.Add(22 * time.Hour), Status: NetworkStatusOperational},
+		{Timestamp: start.Add(23 * time.Hour), Status: NetworkStatusDegraded},
+	}
+
+	report, err := ComputeSLA(transitions, start, start.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, SLAReport{
+		From:          start,
+		To:            start.Add(24 * time.Hour),
+		UptimePercent: 100 * 20.0 / 22,
+		Operational:   20 * time.Hour,
+		Degraded:      2 * time.Hour,
+		Frozen:        2 * time.Hour,
+		IncidentCount: 2,
+		MTTR:          time.Hour,
+		LongestOutage: time.Hour,
+		Incidents: []SLAIncident{
+			{Start: start.Add(10 * time.Hour), End: start.Add(11 * time.Hour), Duration: time.Hour},
+			{Start: start.Add(23 * time.Hour), End: start.Add(24 * time.Hour), Duration: time.Hour, Ongoing: true},
+		},
+	}, report)
+
+	// period before the first transition is unknown
+	report, err = ComputeSLA(transitions[1:2], start, start.Add(11*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Hour, report.Unknown)
+	require.Equal(t, 0.0, report.UptimePercent)
+	require.Equal(t, time.Duration(0), report.MTTR)
+	require.Equal(t, []SLAIncident{
+		{Start: start.Add(10 * time.Hour), End: start.Add(11 * time.Hour), Duration: time.Hour, Ongoing: true},
+	}, report.Incidents)
+
+	report, err = ComputeSLA(nil, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, report.Unknown)
+	require.Empty(t, report.Incidents)
+
+	_, err = ComputeSLA(nil, start, start)
+	require.Error(t, err)
+	_, err = ComputeSLA([]StatusTransition{{Timestamp: start, Status: "bogus"}}, start, start.Add(time.Hour))
+	require.Error(t, err)
+}
+
+func TestNetworkMonitor_NetworkSLA(t *testing.T) {
+	srv := fakestats.NewServer()
+	defer srv.Close()
+	srv.AddNodes(string(MainNetSchemeChar), 100, "aa", "n1", "n2", "n3", "n4")
+
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	clk := clocktest.NewFakeClock(start)
+	store := NewTransitionStore(nil, nil)
+	mon, err := NewNetworkMonitoring(
+		StateActive,
+		MainNetSchemeChar,
+		10,
+		NewNodesStatsScraperHTTP(srv.URL(), DefaultNodeStatsPollResponseSize),
+		1,
+		NetworkErrorCriteria{
+			NodesDown:   NodesDownCriterion{TotalDownNodesPart: 0.3},
+			NodesHeight: NodesHeightCriterion{HeightDiff: 5, RequireMinNodesOnHeight: 2},
+			StateHash: NodesStateHashCriterion{
+				MinStateHashGroupsOnSameHeight:   2,
+				MinValuableStateHashGroups:       2,
+				MinNodesInValuableStateHashGroup: 2,
+				RequireMinNodesOnHeight:          4,
+			},
+		},
+		WithClock(clk),
+		WithTransitionStore(store),
+	)
+	require.NoError(t, err)
+
+	check := func() {
+		require.NoError(t, mon.CheckNodes(context.Background(), clk.Now()))
+		clk.Advance(time.Minute)
+	}
+	check() // 00:00 operational
+	check() // 00:01 still operational, no transition
+	srv.NodesDown("n1", "n2")
+	check() // 00:02 degraded
+	srv.SetHeight(100, "n1", "n2")
+	srv.Fork("aa", "n1", "n2")
+	check() // 00:03 operational
+	mon.ChangeState(StateFrozenNetworkDegraded)
+	clk.Advance(time.Minute)
+	check() // 00:05 still frozen since 00:04
+
+	require.Equal(t, []StatusTransition{
+		{Timestamp: start, Status: NetworkStatusOperational},
+		{Timestamp: start.Add(2 * time.Minute), Status: NetworkStatusDegraded},
+		{Timestamp: start.Add(3 * time.Minute), Status: NetworkStatusOperational},
+		{Timestamp: start.Add(4 * time.Minute), Status: NetworkStatusFrozen},
+	}, store.Transitions(start, start.Add(time.Hour)))
+
+	// period end is limited by the current time
+	report, err := mon.NetworkSLA(start, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, MainNetSchemeChar, report.Network)
+	require.Equal(t, start.Add(6*time.Minute), report.To)
+	require.Equal(t, 3*time.Minute, report.Operational)
+	require.Equal(t, time.Minute, report.Degraded)
+	require.Equal(t, 2*time.Minute, report.Frozen)
+	require.Equal(t, 75.0, report.UptimePercent)
+	require.Equal(t, 1, report.IncidentCount)
+	require.Equal(t, time.Minute, report.MTTR)
+
+	_, err = mon.NetworkSLA(start.Add(time.Hour), start.Add(2*time.Hour))
+	require.Error(t, err)
+}
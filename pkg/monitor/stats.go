@@ -2,6 +2,8 @@ package monitor
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 )
 
 // nodeStats is basic node statistics
@@ -18,6 +20,58 @@ type nodeWithStats struct {
 	nodeStats
 }
 
+const (
+	NodeClassValid     NodeClass = "valid"
+	NodeClassDown      NodeClass = "down"
+	NodeClassSyncing   NodeClass = "syncing" // node reports zero height, e.g. it's syncing from scratch
+	NodeClassMalformed NodeClass = "malformed"
+)
+
+// NodeClass is a result of the node stats validation.
+type NodeClass string
+
+// Classify validates node stats and returns node class. If node is malformed, the reason is returned too.
+// Network scheme isn't checked here, see KnownNetworkSchemeChar.
+func (n *nodeWithStats) Classify() (NodeClass, string) {
+	switch {
+	case n.Height == -1:
+		return NodeClassDown, ""
+	case n.Height == 0:
+		return NodeClassSyncing, ""
+	case n.Height < 0:
+		return NodeClassMalformed, fmt.Sprintf("invalid height %d", n.Height)
+	case n.StateHash == "":
+		return NodeClassMalformed, "empty statehash on working node"
+	case n.StateHashHeight < -1:
+		return NodeClassMalformed, fmt.Sprintf("invalid statehash height %d", n.StateHashHeight)
+	default:
+		return NodeClassValid, ""
+	}
+}
+
+// KnownNetworkSchemeChar checks that netbyte is one of the supported network scheme chars.
+func KnownNetworkSchemeChar(netSchemeChar NetworkSchemeChar) bool {
+	switch netSchemeChar {
+	case MainNetSchemeChar, TestNetSchemeChar, StageNetSchemeChar, CustomNetSchemeChar:
+		return true
+	default:
+		return false
+	}
+}
+
+// NodeInfo is the node stats with the validation result.
+type NodeInfo struct {
+	Domain          string    `json:"domain"`
+	Height          int       `json:"height"`
+	StateHash       string    `json:"statehash"`
+	StateHashHeight int       `json:"statehash_height"`
+	Version         string    `json:"version"`
+	Class           NodeClass `json:"class"`
+	MalformedReason string    `json:"malformed_reason,omitempty"`
+	Lag             int       `json:"lag,omitempty"`     // amount of blocks behind the max height
+	Lagging         bool      `json:"lagging,omitempty"` // lag exceeds height lag criterion
+}
+
 type nodesWithStats []nodeWithStats
 
 func (n *nodesWithStats) UnmarshalJSON(bytes []byte) error {
@@ -38,6 +92,14 @@ func (n *nodesWithStats) UnmarshalJSON(bytes []byte) error {
 	return nil
 }
 
+func (n nodesWithStats) MarshalJSON() ([]byte, error) {
+	nodesStats := make(map[string]nodeStats, len(n))
+	for _, node := range n {
+		nodesStats[node.NodeDomain] = node.nodeStats
+	}
+	return json.Marshal(nodesStats)
+}
+
 func (n nodesWithStats) Filter(condition func(node *nodeWithStats) bool) nodesWithStats {
 	var nodes nodesWithStats
 	for i := range n {
@@ -55,9 +117,12 @@ func (n nodesWithStats) NodesWithNetworkSchemeChar(netSchemeChar NetworkSchemeCh
 	})
 }
 
+// WorkingNodes returns valid nodes with positive height. Malformed nodes aren't trusted, so they aren't working even if
+// their height is positive.
 func (n nodesWithStats) WorkingNodes() nodesWithStats {
 	return n.Filter(func(node *nodeWithStats) bool {
-		return node.Height > 0
+		class, _ := node.Classify()
+		return class == NodeClassValid
 	})
 }
 
@@ -67,6 +132,56 @@ func (n nodesWithStats) DownNodes() nodesWithStats {
 	})
 }
 
+func (n nodesWithStats) MalformedNodes() nodesWithStats {
+	return n.Filter(func(node *nodeWithStats) bool {
+		class, _ := node.Classify()
+		return class == NodeClassMalformed
+	})
+}
+
+func (n nodesWithStats) UnknownNetworkNodes() nodesWithStats {
+	return n.Filter(func(node *nodeWithStats) bool {
+		return !KnownNetworkSchemeChar(node.NetByte)
+	})
+}
+
+func (n nodesWithStats) Domains() []string {
+	domains := make([]string, 0, len(n))
+	for _, node := range n {
+		domains = append(domains, node.NodeDomain)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// NodesInfo returns validated nodes sorted by domain.
+func (n nodesWithStats) NodesInfo() []NodeInfo {
+	nodes := make([]NodeInfo, 0, len(n))
+	for i := range n {
+		node := &n[i]
+		class, reason := node.Classify()
+		nodes = append(nodes, NodeInfo{
+			Domain:          node.NodeDomain,
+			Height:          node.Height,
+			StateHash:       node.StateHash,
+			StateHashHeight: node.StateHashHeight,
+			Version:         node.Version,
+			Class:           class,
+			MalformedReason: reason,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Domain < nodes[j].Domain
+	})
+	return nodes
+}
+
+func (n nodesWithStats) SyncingNodes() nodesWithStats {
+	return n.Filter(func(node *nodeWithStats) bool {
+		return node.Height == 0
+	})
+}
+
 func (n nodesWithStats) SplitByHeight() map[int]nodesWithStats {
 	splitMap := make(map[int]nodesWithStats)
 	for _, node := range n {
@@ -8,12 +8,48 @@ import (
 )
 
 type statsDataSnapshot struct {
-	snapshotCreationTime time.Time
-	nodes                nodesWithStats
-	maxHeight            int
-	nodesDownCriterion   bool
-	heightCriterion      bool
-	stateHashCriterion   bool
+	snapshotCreationTime   time.Time
+	nodes                  nodesWithStats
+	maxHeight              int
+	nodesDownCriterion     bool
+	syncingCriterion       bool
+	heightCriterion        bool
+	heightLagCriterion     bool
+	stateHashCriterion     bool
+	requiredNodesCriterion bool
+	flappingCriterion      bool
+	blockIntervalCriterion bool
+	anomalyCriterion       bool
+}
+
+func (s *statsDataSnapshot) anyCriterionAlerted() bool {
+	return s.nodesDownCriterion || s.syncingCriterion || s.heightCriterion || s.heightLagCriterion ||
+		s.stateHashCriterion || s.requiredNodesCriterion || s.flappingCriterion ||
+		s.blockIntervalCriterion || s.anomalyCriterion
+}
+
+// alertedCriteria returns names of the alerted criteria.
+func (s *statsDataSnapshot) alertedCriteria() []string {
+	var criteria []string
+	for _, criterion := range []struct {
+		name    string
+		alerted bool
+	}{
+		{CriterionNodesDown, s.nodesDownCriterion},
+		{CriterionNodesSyncing, s.syncingCriterion},
+		{CriterionNodesHeight, s.heightCriterion},
+		{CriterionNodesLag, s.heightLagCriterion},
+		{CriterionStateHash, s.stateHashCriterion},
+		{CriterionRequiredNodes, s.requiredNodesCriterion},
+		{CriterionNodesFlapping, s.flappingCriterion},
+		{CriterionBlockInterval, s.blockIntervalCriterion},
+		{CriterionAnomaly, s.anomalyCriterion},
+	} {
+		if criterion.alerted {
+			criteria = append(criteria, criterion.name)
+		}
+	}
+	return criteria
 }
 
 func (s *statsDataSnapshot) String() string {
@@ -21,12 +57,18 @@ func (s *statsDataSnapshot) String() string {
 		return "<nil>"
 	}
 	return fmt.Sprintf(
-		"(snapshotCreationTime: %s, maxHeight: %d, nodesDownCriterion: %t, heightCriterion: %t, stateHashCriterion: %t)",
+		"(snapshotCreationTime: %s, maxHeight: %d, nodesDownCriterion: %t, syncingCriterion: %t, heightCriterion: %t, heightLagCriterion: %t, stateHashCriterion: %t, requiredNodesCriterion: %t, flappingCriterion: %t, blockIntervalCriterion: %t, anomalyCriterion: %t)",
 		s.snapshotCreationTime,
 		s.maxHeight,
 		s.nodesDownCriterion,
+		s.syncingCriterion,
 		s.heightCriterion,
+		s.heightLagCriterion,
 		s.stateHashCriterion,
+		s.requiredNodesCriterion,
+		s.flappingCriterion,
+		s.blockIntervalCriterion,
+		s.anomalyCriterion,
 	)
 }
 
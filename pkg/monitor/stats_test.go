@@ -112,3 +112,51 @@ func TestNodesWithStats_Filter(t *testing.T) {
 	})
 	require.Equal(t, expected, actual)
 }
+
+func TestNodeWithStats_Classify(t *testing.T) {
+	tests := []struct {
+		node      nodeWithStats
+		class     NodeClass
+		malformed bool
+	}{
+		{nodeWithStats{nodeStats: nodeStats{Height: 10, StateHash: "11", StateHashHeight: 9}}, NodeClassValid, false},
+		{nodeWithStats{nodeStats: nodeStats{Height: -1, StateHashHeight: -1}}, NodeClassDown, false},
+		{nodeWithStats{nodeStats: nodeStats{Height: 0, StateHash: "11"}}, NodeClassSyncing, false},
+		{nodeWithStats{nodeStats: nodeStats{Height: 0}}, NodeClassSyncing, false},
+		{nodeWithStats{nodeStats: nodeStats{Height: -2, StateHash: "11"}}, NodeClassMalformed, true},
+		{nodeWithStats{nodeStats: nodeStats{Height: 10, StateHash: ""}}, NodeClassMalformed, true},
+		{nodeWithStats{nodeStats: nodeStats{Height: 10, StateHash: "11", StateHashHeight: -5}}, NodeClassMalformed, true},
+	}
+	for i, tc := range tests {
+		class, reason := tc.node.Classify()
+		require.Equal(t, tc.class, class, "failed testcase #%d", i)
+		require.Equal(t, tc.malformed, reason != "", "failed testcase #%d", i)
+	}
+}
+
+func TestNodesWithStats_NodesInfo(t *testing.T) {
+	data := nodesWithStats{
+		nodeWithStats{NodeDomain: "33", nodeStats: nodeStats{Height: -2, StateHash: "11"}},
+		nodeWithStats{NodeDomain: "44", nodeStats: nodeStats{Height: 0}},
+		nodeWithStats{NodeDomain: "11", nodeStats: nodeStats{Height: -1, StateHashHeight: -1}},
+		nodeWithStats{NodeDomain: "22", nodeStats: nodeStats{Height: 10, StateHash: "11", StateHashHeight: 9, Version: "v1"}},
+	}
+	expected := []NodeInfo{
+		{Domain: "11", Height: -1, StateHashHeight: -1, Class: NodeClassDown},
+		{Domain: "22", Height: 10, StateHash: "11", StateHashHeight: 9, Version: "v1", Class: NodeClassValid},
+		{Domain: "33", Height: -2, StateHash: "11", Class: NodeClassMalformed, MalformedReason: "invalid height -2"},
+		{Domain: "44", Height: 0, Class: NodeClassSyncing},
+	}
+	require.Equal(t, expected, data.NodesInfo())
+	require.Equal(t, []string{"33"}, data.MalformedNodes().Domains())
+	require.Equal(t, []string{"44"}, data.SyncingNodes().Domains())
+}
+
+func TestNodesWithStats_UnknownNetworkNodes(t *testing.T) {
+	data := nodesWithStats{
+		nodeWithStats{NodeDomain: "11", nodeStats: nodeStats{NetByte: MainNetSchemeChar}},
+		nodeWithStats{NodeDomain: "22", nodeStats: nodeStats{NetByte: "X"}},
+		nodeWithStats{NodeDomain: "33", nodeStats: nodeStats{NetByte: ""}},
+	}
+	require.Equal(t, []string{"22", "33"}, data.UnknownNetworkNodes().Domains())
+}
@@ -0,0 +1,146 @@
+package monitor
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	NetworkStatusOperational NetworkStatus = "operational"
+	NetworkStatusDegraded    NetworkStatus = "degraded"
+	NetworkStatusFrozen      NetworkStatus = "frozen"  // monitor is frozen, so the network status is set manually
+	NetworkStatusUnknown     NetworkStatus = "unknown" // monitor isn't running or hasn't checked the network yet
+)
+
+// NetworkStatus is the network status from the monitor point of view which is tracked for SLA reporting.
+type NetworkStatus string
+
+// StatusTransition is the moment when the network status has changed. The status lasts till the next transition.
+type StatusTransition struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Status    NetworkStatus `json:"status"`
+}
+
+// TransitionStore keeps status transitions in memory ordered by time and, if the writer is set,
+// appends them to the writer as JSON lines.
+type TransitionStore struct {
+	mu          sync.RWMutex
+	transitions []StatusTransition
+	enc         *json.Encoder
+}
+
+// NewTransitionStore creates the store with previously persisted transitions, see ReadStatusTransitions.
+// Writer can be nil, in this case transitions are kept only in memory.
+func NewTransitionStore(w io.Writer, persisted []StatusTransition) *TransitionStore {
+	transitions := make([]StatusTransition, len(persisted))
+	copy(transitions, persisted)
+	sort.SliceStable(transitions, func(i, j int) bool {
+		return transitions[i].Timestamp.Before(transitions[j].Timestamp)
+	})
+	s := &TransitionStore{transitions: transitions}
+	if w != nil {
+		s.enc = json.NewEncoder(w)
+	}
+	return s
+}
+
+func (s *TransitionStore) Append(transition StatusTransition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n := len(s.transitions); n != 0 && transition.Timestamp.Before(s.transitions[n-1].Timestamp) {
+		return errors.Errorf("status transition at %s is older than the latest one at %s",
+			transition.Timestamp, s.transitions[n-1].Timestamp,
+		)
+	}
+	if s.enc != nil {
+		if err := s.enc.Encode(transition); err != nil {
+			return errors.Wrap(err, "failed to write status transition")
+		}
+	}
+	s.transitions = append(s.transitions, transition)
+	return nil
+}
+
+// Last returns the latest transition, it returns false if the store is empty.
+func (s *TransitionStore) Last() (StatusTransition, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.transitions) == 0 {
+		return StatusTransition{}, false
+	}
+	return s.transitions[len(s.transitions)-1], true
+}
+
+// Transitions returns transitions within [from, to) period and the latest transition before the period,
+// because it defines the status at the beginning of the period.
+func (s *TransitionStore) Transitions(from, to time.Time) []StatusTransition {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	start := sort.Search(len(s.transitions), func(i int) bool {
+		return !s.transitions[i].Timestamp.Before(from)
+	})
+	if start > 0 && (start == len(s.transitions) || s.transitions[start].Timestamp.After(from)) {
+		start--
+	}
+	end := sort.Search(len(s.transitions), func(i int) bool {
+		return !s.transitions[i].Timestamp.Before(to)
+	})
+	if start >= end {
+		return nil
+	}
+	transitions := make([]StatusTransition, end-start)
+	copy(transitions, s.transitions[start:end])
+	return transitions
+}
+
+// ReadStatusTransitions reads all transitions written by TransitionStore.
+func ReadStatusTransitions(r io.Reader) ([]StatusTransition, error) {
+	var transitions []StatusTransition
+	dec := json.NewDecoder(r)
+	for {
+		var transition StatusTransition
+		if err := dec.Decode(&transition); err != nil {
+			if errors.Is(err, io.EOF) {
+				return transitions, nil
+			}
+			return nil, errors.Wrapf(err, "failed to read status transition #%d", len(transitions))
+		}
+		transitions = append(transitions, transition)
+	}
+}
+
+// readLastSeen reads the time written by writeLastSeen, zero time is returned if the file doesn't exist.
+func readLastSeen(path string) (time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, errors.Wrap(err, "failed to read last seen file")
+	}
+	lastSeen, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid last seen file %q", path)
+	}
+	return lastSeen, nil
+}
+
+// writeLastSeen replaces the file content with the given time. The file is replaced by renaming, so it's never
+// left partially written.
+func writeLastSeen(path string, lastSeen time.Time) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(lastSeen.Format(time.RFC3339Nano)+"\n"), 0644); err != nil {
+		return errors.Wrap(err, "failed to write last seen file")
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Wrap(err, "failed to replace last seen file")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package monitor
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitionStore(t *testing.T) {
+	start := time.Date(2021, 12, 2, 19, 0, 0, 0, time.UTC)
+	persisted := []StatusTransition{
+		{Timestamp: start.Add(time.Hour), Status: NetworkStatusDegraded},
+		{Timestamp: start, Status: NetworkStatusOperational},
+	}
+	buf := new(bytes.Buffer)
+	store := NewTransitionStore(buf, persisted)
+
+	last, ok := store.Last()
+	require.True(t, ok)
+	require.Equal(t, persisted[0], last)
+
+	require.Error(t, store.Append(StatusTransition{Timestamp: start, Status: NetworkStatusFrozen}))
+	appended := StatusTransition{Timestamp: start.Add(2 * time.Hour), Status: NetworkStatusOperational}
+	require.NoError(t, store.Append(appended))
+
+	// only new transitions are written
+	written, err := ReadStatusTransitions(buf)
+	require.NoError(t, err)
+	require.Equal(t, []StatusTransition{appended}, written)
+
+	require.Equal(t, []StatusTransition{persisted[1], persisted[0]},
+		store.Transitions(start.Add(30*time.Minute), start.Add(2*time.Hour)),
+	)
+	require.Equal(t, []StatusTransition{persisted[0], appended},
+		store.Transitions(start.Add(time.Hour), start.Add(3*time.Hour)),
+	)
+	require.Empty(t, store.Transitions(start.Add(-2*time.Hour), start.Add(-time.Hour)))
+
+	empty := NewTransitionStore(nil, nil)
+	_, ok = empty.Last()
+	require.False(t, ok)
+	require.NoError(t, empty.Append(appended))
+}
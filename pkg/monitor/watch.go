@@ -0,0 +1,62 @@
+package monitor
+
+import "sync"
+
+// statusWatchers keeps channels of status watchers. Each channel has the buffer of one status, if the watcher
+// hasn't read the previous status yet, it's replaced with the newer one, so slow watchers don't block the monitor.
+type statusWatchers struct {
+	mu       sync.Mutex
+	nextID   int
+	watchers map[int]chan NetworkStatusInfo
+}
+
+func (w *statusWatchers) add() (int, chan NetworkStatusInfo) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.watchers == nil {
+		w.watchers = make(map[int]chan NetworkStatusInfo)
+	}
+	w.nextID++
+	ch := make(chan NetworkStatusInfo, 1)
+	w.watchers[w.nextID] = ch
+	return w.nextID, ch
+}
+
+func (w *statusWatchers) remove(id int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if ch, ok := w.watchers[id]; ok {
+		delete(w.watchers, id)
+		close(ch)
+	}
+}
+
+func (w *statusWatchers) notify(status NetworkStatusInfo) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, ch := range w.watchers {
+		select {
+		case ch <- status:
+		default:
+			// drop the unread status and send the newer one
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- status
+		}
+	}
+}
+
+// WatchStatus subscribes to the network status which is sent after each successful CheckNodes. Updates channel is
+// closed by the cancel function, which must be called when the watcher is done.
+func (m *NetworkMonitor) WatchStatus() (updates <-chan NetworkStatusInfo, cancel func()) {
+	id, ch := m.watchers.add()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() { m.watchers.remove(id) })
+	}
+}
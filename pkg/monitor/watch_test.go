@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nickeskov/netmon/pkg/clock/clocktest"
+	"github.com/nickeskov/netmon/pkg/fakestats"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkMonitor_WatchStatus(t *testing.T) {
+	srv := fakestats.NewServer()
+	defer srv.Close()
+	srv.AddNodes(string(MainNetSchemeChar), 100, "aa", "n1", "n2")
+
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	clk := clocktest.NewFakeClock(start)
+	mon, err := NewNetworkMonitoring(
+		StateActive,
+		MainNetSchemeChar,
+		10,
+		NewNodesStatsScraperHTTP(srv.URL(), DefaultNodeStatsPollResponseSize),
+		2,
+		NetworkErrorCriteria{},
+		WithClock(clk),
+	)
+	require.NoError(t, err)
+
+	updates, cancel := mon.WatchStatus()
+	slowUpdates, slowCancel := mon.WatchStatus()
+	defer slowCancel()
+
+	require.NoError(t, mon.CheckNodes(context.Background(), start))
+	status := <-updates
+	require.Equal(t, start, status.Updated)
+	require.Equal(t, 100, status.Height)
+
+	srv.SetHeight(101, "n1", "n2")
+	require.NoError(t, mon.CheckNodes(context.Background(), start.Add(time.Minute)))
+	require.Equal(t, 101, (<-updates).Height)
+	// slow watcher gets only the latest status
+	require.Equal(t, 101, (<-slowUpdates).Height)
+	require.Empty(t, slowUpdates)
+
+	cancel()
+	cancel() // cancel is idempotent
+	_, ok := <-updates
+	require.False(t, ok)
+
+	// failed check doesn't notify watchers
+	srv.Close()
+	require.Error(t, mon.CheckNodes(context.Background(), start.Add(2*time.Minute)))
+	require.Empty(t, slowUpdates)
+}
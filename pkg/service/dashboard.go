@@ -0,0 +1,42 @@
+package service
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// dashboardHTML is the self-contained dashboard page, it uses only the versioned API and has no external resources.
+//
+//go:embed dashboard.html
+var dashboardHTML string
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(dashboardHTML))
+
+// dashboardCSP forbids loading of any resources except the API, so the dashboard works offline.
+const dashboardCSP = "default-src 'none'; connect-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'"
+
+// Dashboard serves the HTML dashboard. Freezing and unfreezing of the monitor from the dashboard require the token
+// which is sent in the configured auth header.
+func (s *NetworkMonitoringService) Dashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := struct {
+		APIPrefix  string
+		AuthHeader string
+	}{
+		APIPrefix:  APIV1Prefix,
+		AuthHeader: s.authHeader,
+	}
+	w.Header().Set("content-type", "text/html; charset=utf-8")
+	w.Header().Set("content-security-policy", dashboardCSP)
+	if err := dashboardTemplate.Execute(w, data); err != nil {
+		zap.S().Errorf("failed to execute dashboard template: %v", err)
+		writeStatusError(w, http.StatusInternalServerError)
+	}
+}
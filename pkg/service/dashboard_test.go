@@ -0,0 +1,31 @@
+package service
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkMonitoringService_Dashboard(t *testing.T) {
+	netMon := NewNetworkMonitoringService(nil, WithAuthHeader("X-Custom-Auth"))
+
+	w := httptest.NewRecorder()
+	netMon.Dashboard(w, httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	require.Equal(t, "text/html; charset=utf-8", w.Header().Get("content-type"))
+	require.Equal(t, dashboardCSP, w.Header().Get("content-security-policy"))
+	body, err := io.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `const api = "." + "/api/v1";`)
+	require.Contains(t, string(body), `const authHeader = "X-Custom-Auth";`)
+	// dashboard must work offline, so it must not load anything from other hosts
+	require.NotRegexp(t, regexp.MustCompile(`(src|href)=["']?(https?:)?//`), string(body))
+
+	w = httptest.NewRecorder()
+	netMon.Dashboard(w, httptest.NewRequest(http.MethodPost, "/dashboard", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/nickeskov/netmon/pkg/monitor"
+)
+
+// DebugVars serves the netmon metrics in the expvar JSON format. Unlike expvar.Handler, standard 'cmdline' and
+// 'memstats' vars are left out, because the command line may contain the auth token.
+func DebugVars(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("content-type", "application/json; charset=utf-8")
+	var sb strings.Builder
+	sb.WriteString("{\n")
+	first := true
+	expvar.Do(func(kv expvar.KeyValue) {
+		if !strings.HasPrefix(kv.Key, monitor.MetricsPrefix) {
+			return
+		}
+		if !first {
+			sb.WriteString(",\n")
+		}
+		first = false
+		_, _ = fmt.Fprintf(&sb, "%q: %s", kv.Key, kv.Value)
+	})
+	sb.WriteString("\n}\n")
+	_, _ = w.Write([]byte(sb.String()))
+}
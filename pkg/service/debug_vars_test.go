@@ -0,0 +1,29 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nickeskov/netmon/pkg/monitor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugVars(t *testing.T) {
+	w := httptest.NewRecorder()
+	DebugVars(w, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	vars := map[string]json.RawMessage{}
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&vars))
+	require.Contains(t, vars, monitor.MetricsPrefix+"scrape_response_size_bytes")
+	require.NotContains(t, vars, "cmdline")
+	for name := range vars {
+		require.True(t, strings.HasPrefix(name, monitor.MetricsPrefix), name)
+	}
+
+	w = httptest.NewRecorder()
+	DebugVars(w, httptest.NewRequest(http.MethodPost, "/debug/vars", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+}
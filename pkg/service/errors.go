@@ -0,0 +1,27 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// ErrorResponse is the body of all error responses, e.g. '{"code":404,"error":"Not Found"}'.
+type ErrorResponse struct {
+	Code  int    `json:"code"`
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("x-content-type-options", "nosniff")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(ErrorResponse{Code: code, Error: message}); err != nil {
+		zap.S().Errorf("failed to marshal error response struct: %v", err)
+	}
+}
+
+func writeStatusError(w http.ResponseWriter, code int) {
+	writeError(w, code, http.StatusText(code))
+}
@@ -1,12 +1,23 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+)
 
 func NewHTTPAuthTokenMiddleware(header, token string) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.Header.Get(header) != token {
-				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				zap.S().Warnw("auth token check has failed",
+					"api", "http", "method", r.Method, "path", r.URL.Path, "remote_addr", r.RemoteAddr,
+				)
+				// the same body as service error responses
+				w.Header().Set("content-type", "application/json")
+				w.Header().Set("x-content-type-options", "nosniff")
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"code":403,"error":"Forbidden"}` + "\n"))
 				return
 			}
 			next.ServeHTTP(w, r)
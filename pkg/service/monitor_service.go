@@ -1,40 +1,541 @@
 package service
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/nickeskov/netmon/pkg/clock"
 	"github.com/nickeskov/netmon/pkg/monitor"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
+// HealthStatusCodes are HTTP status codes of the health status endpoint by network severity.
+// Stale code is used if the latest statistics are older than the max staleness.
+type HealthStatusCodes struct {
+	OK       int
+	Warning  int
+	Degraded int
+	Critical int
+	Stale    int
+}
+
+// DefaultHealthStatusCodes returns 503 Service Unavailable for degraded, critical and stale network status.
+func DefaultHealthStatusCodes() HealthStatusCodes {
+	return HealthStatusCodes{
+		OK:       http.StatusOK,
+		Warning:  http.StatusOK,
+		Degraded: http.StatusServiceUnavailable,
+		Critical: http.StatusServiceUnavailable,
+		Stale:    http.StatusServiceUnavailable,
+	}
+}
+
+func (c *HealthStatusCodes) Validate() error {
+	for _, code := range []int{c.OK, c.Warning, c.Degraded, c.Critical, c.Stale} {
+		if code < 100 || code > 599 {
+			return errors.Errorf("invalid HTTP status code %d", code)
+		}
+	}
+	return nil
+}
+
+func (c *HealthStatusCodes) code(severity monitor.Severity, stale bool) int {
+	if stale {
+		return c.Stale
+	}
+	switch severity {
+	case monitor.SeverityOK:
+		return c.OK
+	case monitor.SeverityWarning:
+		return c.Warning
+	case monitor.SeverityDegraded:
+		return c.Degraded
+	default:
+		return c.Critical
+	}
+}
+
+// DefaultLivenessTimeout is the max duration of a scrape and the max delay of a monitor loop iteration
+// after which the monitor is considered stuck.
+const DefaultLivenessTimeout = 5 * time.Minute
+
+// DefaultAuthHeader is the HTTP header in which the token for private routes is passed.
+const DefaultAuthHeader = "X-Waves-Monitor-Auth"
+
 type NetworkMonitoringService struct {
-	monitor monitor.Monitor
+	monitor           monitor.Monitor
+	clock             clock.Clock
+	healthStatusCodes HealthStatusCodes
+	healthMaxStale    time.Duration
+	livenessTimeout   time.Duration
+	authHeader        string
+}
+
+type networkMonitoringServiceOptions struct {
+	clock             clock.Clock
+	healthStatusCodes HealthStatusCodes
+	healthMaxStale    time.Duration
+	livenessTimeout   time.Duration
+	authHeader        string
+}
+
+type NetworkMonitoringServiceOption func(o *networkMonitoringServiceOptions)
+
+// WithClock sets the clock which is used to check staleness of the network status. Real clock is used by default.
+func WithClock(c clock.Clock) NetworkMonitoringServiceOption {
+	return func(o *networkMonitoringServiceOptions) {
+		o.clock = c
+	}
+}
+
+// WithHealthStatusCodes sets HTTP status codes of the health status endpoint, see DefaultHealthStatusCodes.
+func WithHealthStatusCodes(codes HealthStatusCodes) NetworkMonitoringServiceOption {
+	return func(o *networkMonitoringServiceOptions) {
+		o.healthStatusCodes = codes
+	}
+}
+
+// WithHealthMaxStaleness sets the max age of the latest statistics after which the network status is stale.
+// Zero value disables the staleness check.
+func WithHealthMaxStaleness(d time.Duration) NetworkMonitoringServiceOption {
+	return func(o *networkMonitoringServiceOptions) {
+		o.healthMaxStale = d
+	}
+}
+
+// WithLivenessTimeout sets the liveness timeout, see DefaultLivenessTimeout.
+func WithLivenessTimeout(d time.Duration) NetworkMonitoringServiceOption {
+	return func(o *networkMonitoringServiceOptions) {
+		o.livenessTimeout = d
+	}
+}
+
+// WithAuthHeader sets the auth header which is used by the dashboard to access private routes, see DefaultAuthHeader.
+func WithAuthHeader(header string) NetworkMonitoringServiceOption {
+	return func(o *networkMonitoringServiceOptions) {
+		o.authHeader = header
+	}
 }
 
-func NewNetworkMonitoringService(monitor monitor.Monitor) NetworkMonitoringService {
+func NewNetworkMonitoringService(monitor monitor.Monitor, opts ...NetworkMonitoringServiceOption) NetworkMonitoringService {
+	options := networkMonitoringServiceOptions{
+		clock:             clock.Real(),
+		healthStatusCodes: DefaultHealthStatusCodes(),
+		livenessTimeout:   DefaultLivenessTimeout,
+		authHeader:        DefaultAuthHeader,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
 	return NetworkMonitoringService{
-		monitor: monitor,
+		monitor:           monitor,
+		clock:             options.clock,
+		healthStatusCodes: options.healthStatusCodes,
+		healthMaxStale:    options.healthMaxStale,
+		livenessTimeout:   options.livenessTimeout,
+		authHeader:        options.authHeader,
 	}
 }
 
 func (s *NetworkMonitoringService) NetworkHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		writeStatusError(w, http.StatusMethodNotAllowed)
 		return
 	}
 
 	w.Header().Set("content-type", "application/json")
 	if err := json.NewEncoder(w).Encode(s.monitor.NetworkStatusInfo()); err != nil {
 		zap.S().Errorf("failed to marshal status response struct: %v", err)
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		writeStatusError(w, http.StatusInternalServerError)
+	}
+}
+
+type healthStatusResponse struct {
+	Updated  time.Time        `json:"updated,omitempty"`
+	Status   bool             `json:"status"`
+	Severity monitor.Severity `json:"severity"`
+	Stale    bool             `json:"stale"`
+}
+
+// NetworkHealthStatus is the health endpoint for load balancers and health checkers which only look at HTTP status
+// codes. Status code depends on the network severity and staleness, HEAD requests get no body. Frozen monitor doesn't
+// scrape, so its status is never stale.
+func (s *NetworkMonitoringService) NetworkHealthStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	info := s.monitor.NetworkStatusInfo()
+	resp := healthStatusResponse{
+		Updated:  info.Updated,
+		Status:   info.Status,
+		Severity: info.Severity,
+		Stale: s.healthMaxStale > 0 && (info.Updated.IsZero() || s.clock.Now().Sub(info.Updated) > s.healthMaxStale) &&
+			s.monitor.State() == monitor.StateActive,
+	}
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("cache-control", "no-store")
+	w.WriteHeader(s.healthStatusCodes.code(resp.Severity, resp.Stale))
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		zap.S().Errorf("failed to marshal health status response struct: %v", err)
+	}
+}
+
+type probeResponse struct {
+	Status string           `json:"status"`
+	Reason string           `json:"reason,omitempty"`
+	Loop   monitor.LoopInfo `json:"loop"`
+}
+
+// Livez reports whether the monitor loop is still iterating and isn't stuck in a scrape.
+func (s *NetworkMonitoringService) Livez(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	loop := s.monitor.LoopInfo()
+	now := s.clock.Now()
+	var reason string
+	switch {
+	case loop.Started.IsZero():
+		reason = "monitor loop hasn't been started"
+	case !loop.ScrapeStarted.IsZero() && now.Sub(loop.ScrapeStarted) > s.livenessTimeout:
+		reason = fmt.Sprintf("scrape has been running for %s", now.Sub(loop.ScrapeStarted))
+	case now.Sub(loop.LastIteration) > loop.PollInterval+s.livenessTimeout:
+		reason = fmt.Sprintf("monitor loop hasn't iterated for %s", now.Sub(loop.LastIteration))
+	}
+	writeProbe(w, r, probeResponse{Reason: reason, Loop: loop})
+}
+
+// Readyz reports whether at least one scrape has succeeded since startup. Frozen monitor doesn't scrape,
+// so it's always ready.
+func (s *NetworkMonitoringService) Readyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	loop := s.monitor.LoopInfo()
+	var reason string
+	if loop.LastSuccessfulScrape.IsZero() && loop.State == monitor.StateActive.String() {
+		reason = "no successful scrape since startup"
+	}
+	writeProbe(w, r, probeResponse{Reason: reason, Loop: loop})
+}
+
+// writeProbe writes 200 OK if the probe reason is empty and 503 Service Unavailable otherwise.
+func writeProbe(w http.ResponseWriter, r *http.Request, resp probeResponse) {
+	code := http.StatusOK
+	resp.Status = "ok"
+	if resp.Reason != "" {
+		code = http.StatusServiceUnavailable
+		resp.Status = "fail"
+	}
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("cache-control", "no-store")
+	w.WriteHeader(code)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		zap.S().Errorf("failed to marshal probe response struct: %v", err)
+	}
+}
+
+func (s *NetworkMonitoringService) NetworkNodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.monitor.NetworkNodesInfo()); err != nil {
+		zap.S().Errorf("failed to marshal nodes response struct: %v", err)
+		writeStatusError(w, http.StatusInternalServerError)
+	}
+}
+
+func (s *NetworkMonitoringService) NetworkForks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.monitor.NetworkForksInfo()); err != nil {
+		zap.S().Errorf("failed to marshal forks response struct: %v", err)
+		writeStatusError(w, http.StatusInternalServerError)
+	}
+}
+
+func (s *NetworkMonitoringService) NetworkFlappingNodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.monitor.NetworkFlappingInfo()); err != nil {
+		zap.S().Errorf("failed to marshal flapping nodes response struct: %v", err)
+		writeStatusError(w, http.StatusInternalServerError)
+	}
+}
+
+func (s *NetworkMonitoringService) NetworkHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.monitor.NetworkHistoryInfo()); err != nil {
+		zap.S().Errorf("failed to marshal history response struct: %v", err)
+		writeStatusError(w, http.StatusInternalServerError)
+	}
+}
+
+func (s *NetworkMonitoringService) NetworkChain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.monitor.NetworkChainInfo()); err != nil {
+		zap.S().Errorf("failed to marshal chain response struct: %v", err)
+		writeStatusError(w, http.StatusInternalServerError)
+	}
+}
+
+func (s *NetworkMonitoringService) NetworkBaselines(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.monitor.NetworkBaselinesInfo()); err != nil {
+		zap.S().Errorf("failed to marshal baselines response struct: %v", err)
+		writeStatusError(w, http.StatusInternalServerError)
+	}
+}
+
+func (s *NetworkMonitoringService) NetworkNodesScores(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.monitor.NetworkNodesScoresInfo()); err != nil {
+		zap.S().Errorf("failed to marshal nodes scores response struct: %v", err)
+		writeStatusError(w, http.StatusInternalServerError)
+	}
+}
+
+func (s *NetworkMonitoringService) NetworkIncidents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.monitor.NetworkIncidentsInfo()); err != nil {
+		zap.S().Errorf("failed to marshal incidents response struct: %v", err)
+		writeStatusError(w, http.StatusInternalServerError)
+	}
+}
+
+// NetworkIncident serves the incident by ID from the URL path, e.g. '/incidents/42'.
+func (s *NetworkMonitoringService) NetworkIncident(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := incidentPathID(r.URL.Path, "")
+	if !ok {
+		writeStatusError(w, http.StatusNotFound)
+		return
+	}
+	incident, err := s.monitor.NetworkIncident(id)
+	if err != nil {
+		writeStatusError(w, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(incident); err != nil {
+		zap.S().Errorf("failed to marshal incident response struct: %v", err)
+		writeStatusError(w, http.StatusInternalServerError)
+	}
+}
+
+// AddIncidentNote MUST be protected by auth middleware
+func (s *NetworkMonitoringService) AddIncidentNote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := incidentPathID(r.URL.Path, incidentNotesSuffix)
+	if !ok {
+		writeStatusError(w, http.StatusNotFound)
+		return
+	}
+
+	type addNoteRequest struct {
+		Author string `json:"author"`
+		Text   string `json:"text"`
+	}
+
+	var jsonRequest addNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&jsonRequest); err != nil {
+		writeStatusError(w, http.StatusBadRequest)
+		zap.S().Warnf("invalid add incident note request, failed to parse JSON: %v", err)
+		return
+	}
+	if strings.TrimSpace(jsonRequest.Text) == "" {
+		writeStatusError(w, http.StatusBadRequest)
+		zap.S().Warnf("invalid add incident note request, empty note text for incident #%d", id)
+		return
+	}
+
+	incident, err := s.monitor.AddIncidentNote(id, jsonRequest.Author, jsonRequest.Text)
+	if err != nil {
+		if errors.Is(err, monitor.ErrIncidentNotFound) {
+			writeStatusError(w, http.StatusNotFound)
+			return
+		}
+		zap.S().Errorf("failed to add note to incident #%d: %v", id, err)
+		writeStatusError(w, http.StatusInternalServerError)
+		return
+	}
+	zap.S().Infof("note has been added to incident #%d", id)
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(incident); err != nil {
+		zap.S().Errorf("failed to marshal incident response struct: %v", err)
+		writeStatusError(w, http.StatusInternalServerError)
+	}
+}
+
+const incidentNotesSuffix = "/notes"
+
+// incidentPathID returns the incident ID of the /incidents/{id}<suffix> path, the path may start with APIV1Prefix.
+// ok is false if there is not exactly one path segment between /incidents/ and the suffix or it isn't a number.
+func incidentPathID(path, suffix string) (id int, ok bool) {
+	rest, ok := strings.CutPrefix(strings.TrimPrefix(path, APIV1Prefix), "/incidents/")
+	if !ok {
+		return 0, false
+	}
+	rest, ok = strings.CutSuffix(rest, suffix)
+	if !ok || rest == "" || strings.Contains(rest, "/") {
+		return 0, false
+	}
+	id, err := strconv.Atoi(rest)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+const defaultSLAPeriod = 30 * 24 * time.Hour
+
+// NetworkSLA serves SLA report over [from, to) period. Query parameters 'from' and 'to' are RFC3339 timestamps,
+// by default the report is calculated over the last 30 days. Parameter 'format' is 'json' (default) or 'csv'.
+func (s *NetworkMonitoringService) NetworkSLA(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	to := s.clock.Now().UTC()
+	if v := query.Get("to"); v != "" {
+		var err error
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid 'to' parameter, RFC3339 timestamp is expected")
+			return
+		}
+	}
+	from := to.Add(-defaultSLAPeriod)
+	if v := query.Get("from"); v != "" {
+		var err error
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid 'from' parameter, RFC3339 timestamp is expected")
+			return
+		}
+	}
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "invalid 'format' parameter, 'json' or 'csv' is expected")
+		return
+	}
+
+	report, err := s.monitor.NetworkSLA(from, to)
+	if err != nil {
+		zap.S().Warnf("failed to calculate SLA report: %v", err)
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if format == "csv" {
+		w.Header().Set("content-type", "text/csv")
+		if err := writeSLAReportCSV(w, report); err != nil {
+			zap.S().Errorf("failed to write SLA report CSV: %v", err)
+		}
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		zap.S().Errorf("failed to marshal SLA response struct: %v", err)
+		writeStatusError(w, http.StatusInternalServerError)
+	}
+}
+
+func writeSLAReportCSV(w io.Writer, report monitor.SLAReport) error {
+	seconds := func(d time.Duration) string {
+		return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
 	}
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"network", "from", "to", "uptime_percent", "operational_seconds", "degraded_seconds", "frozen_seconds",
+		"unknown_seconds", "incident_count", "mttr_seconds", "longest_outage_seconds",
+	})
+	_ = cw.Write([]string{
+		string(report.Network),
+		report.From.Format(time.RFC3339),
+		report.To.Format(time.RFC3339),
+		strconv.FormatFloat(report.UptimePercent, 'f', 4, 64),
+		seconds(report.Operational),
+		seconds(report.Degraded),
+		seconds(report.Frozen),
+		seconds(report.Unknown),
+		strconv.Itoa(report.IncidentCount),
+		seconds(report.MTTR),
+		seconds(report.LongestOutage),
+	})
+	cw.Flush()
+	return cw.Error()
 }
 
 // SetMonitorState MUST be protected by auth middleware
 func (s *NetworkMonitoringService) SetMonitorState(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		writeStatusError(w, http.StatusMethodNotAllowed)
 		return
 	}
 
@@ -44,21 +545,26 @@ func (s *NetworkMonitoringService) SetMonitorState(w http.ResponseWriter, r *htt
 
 	var jsonRequest stateChangeRequest
 	if err := json.NewDecoder(r.Body).Decode(&jsonRequest); err != nil {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		zap.S().Warnf("invalid set monitor state request, failed to parse JSON: %v", err)
+		writeStatusError(w, http.StatusBadRequest)
+		zap.S().Warnw("invalid set monitor state request, failed to parse JSON",
+			"api", "http", "remote_addr", r.RemoteAddr, zap.Error(err),
+		)
 		return
 	}
 
 	newMonState, err := monitor.NewNetworkMonitoringStateFromString(jsonRequest.State)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		zap.S().Warnf("invalid set monitor state request, invalid state string value: %v", err)
+		writeStatusError(w, http.StatusBadRequest)
+		zap.S().Warnw("invalid set monitor state request, invalid state string value",
+			"api", "http", "remote_addr", r.RemoteAddr, zap.Error(err),
+		)
 		return
 	}
 	prevMonState := s.monitor.ChangeState(newMonState)
+	fields := []any{"api", "http", "remote_addr", r.RemoteAddr, "from", prevMonState.String(), "to", newMonState.String()}
 	if prevMonState != newMonState {
-		zap.S().Infof("monitor state has been successfully changed from %q to %q", prevMonState, newMonState)
+		zap.S().Infow("monitor state has been successfully changed", fields...)
 	} else {
-		zap.S().Infof("monitor state hasn't been changed, current state is %q", prevMonState)
+		zap.S().Infow("monitor state hasn't been changed", fields...)
 	}
 }
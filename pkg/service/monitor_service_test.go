@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/nickeskov/netmon/pkg/clock/clocktest"
 	"github.com/nickeskov/netmon/pkg/monitor"
 	"github.com/stretchr/testify/require"
 )
@@ -36,6 +37,24 @@ func TestNetworkMonitoringService_NetworkHealth(t *testing.T) {
 				Height:  12345,
 			},
 		},
+		{
+			testName:       "CriticalSeverity",
+			httpMethod:     http.MethodGet,
+			httpStatusCode: http.StatusOK,
+			mockCallTimes:  1,
+			statusInfo: monitor.NetworkStatusInfo{
+				Updated:  now,
+				Network:  monitor.MainNetSchemeChar,
+				Status:   false,
+				Height:   12345,
+				Severity: monitor.SeverityCritical,
+				Reasons: []monitor.SeverityReason{{
+					Reason:   monitor.CriterionStateHash,
+					Severity: monitor.SeverityCritical,
+					Since:    time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC),
+				}},
+			},
+		},
 		{
 			testName:       "HTTPMethodPost",
 			httpMethod:     http.MethodPost,
@@ -83,6 +102,553 @@ func TestNetworkMonitoringService_NetworkHealth(t *testing.T) {
 	}
 }
 
+func TestNetworkMonitoringService_NetworkHealthStatus(t *testing.T) {
+	now := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	codes := DefaultHealthStatusCodes()
+	codes.Warning = http.StatusTooManyRequests
+	tests := []struct {
+		testName   string
+		httpMethod string
+		updated    time.Time
+		severity   monitor.Severity
+		state      monitor.NetworkMonitoringState
+		code       int
+		stale      bool
+	}{
+		{"OK", http.MethodGet, now, monitor.SeverityOK, monitor.StateActive, http.StatusOK, false},
+		{"Warning", http.MethodGet, now, monitor.SeverityWarning, monitor.StateActive, http.StatusTooManyRequests, false},
+		{"Degraded", http.MethodGet, now.Add(-time.Minute), monitor.SeverityDegraded, monitor.StateActive, http.StatusServiceUnavailable, false},
+		{"Critical", http.MethodGet, now, monitor.SeverityCritical, monitor.StateActive, http.StatusServiceUnavailable, false},
+		{"Stale", http.MethodGet, now.Add(-6 * time.Minute), monitor.SeverityOK, monitor.StateActive, http.StatusServiceUnavailable, true},
+		{"NoStats", http.MethodGet, time.Time{}, monitor.SeverityOK, monitor.StateActive, http.StatusServiceUnavailable, true},
+		{"FrozenStable", http.MethodGet, now.Add(-time.Hour), monitor.SeverityOK, monitor.StateFrozenNetworkOperatesStable, http.StatusOK, false},
+		{"FrozenNoStats", http.MethodGet, time.Time{}, monitor.SeverityOK, monitor.StateFrozenNetworkOperatesStable, http.StatusOK, false},
+		{"HEAD", http.MethodHead, now, monitor.SeverityCritical, monitor.StateActive, http.StatusServiceUnavailable, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockMonitor := monitor.NewMockMonitor(ctrl)
+			mockMonitor.EXPECT().NetworkStatusInfo().Times(1).Return(monitor.NetworkStatusInfo{
+				Updated:  tc.updated,
+				Network:  monitor.MainNetSchemeChar,
+				Status:   tc.severity == monitor.SeverityOK,
+				Severity: tc.severity,
+			})
+			mockMonitor.EXPECT().State().AnyTimes().Return(tc.state)
+			netMon := NewNetworkMonitoringService(mockMonitor,
+				WithClock(clocktest.NewFakeClock(now)),
+				WithHealthStatusCodes(codes),
+				WithHealthMaxStaleness(5*time.Minute),
+			)
+
+			w := httptest.NewRecorder()
+			netMon.NetworkHealthStatus(w, httptest.NewRequest(tc.httpMethod, "/health/status", nil))
+			require.Equal(t, tc.code, w.Result().StatusCode)
+			if tc.httpMethod == http.MethodHead {
+				require.Empty(t, w.Body.Bytes())
+				return
+			}
+			var resp healthStatusResponse
+			require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&resp))
+			require.Equal(t, tc.severity, resp.Severity)
+			require.Equal(t, tc.stale, resp.Stale)
+		})
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockMonitor := monitor.NewMockMonitor(ctrl)
+	mockMonitor.EXPECT().NetworkStatusInfo().Times(1).Return(monitor.NetworkStatusInfo{Severity: monitor.SeverityOK})
+	netMon := NewNetworkMonitoringService(mockMonitor) // staleness check is disabled by default
+	w := httptest.NewRecorder()
+	netMon.NetworkHealthStatus(w, httptest.NewRequest(http.MethodGet, "/health/status", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	w = httptest.NewRecorder()
+	netMon.NetworkHealthStatus(w, httptest.NewRequest(http.MethodPost, "/health/status", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+
+	codes.Stale = 600
+	require.Error(t, codes.Validate())
+	codes = DefaultHealthStatusCodes()
+	require.NoError(t, codes.Validate())
+}
+
+func TestNetworkMonitoringService_Probes(t *testing.T) {
+	now := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	running := monitor.LoopInfo{
+		Started:              now.Add(-time.Hour),
+		LastIteration:        now.Add(-time.Minute),
+		PollInterval:         time.Minute,
+		LastSuccessfulScrape: now.Add(-time.Minute),
+		State:                monitor.StateActive.String(),
+	}
+	tests := []struct {
+		testName   string
+		httpMethod string
+		loop       func(l monitor.LoopInfo) monitor.LoopInfo
+		livez      int
+		readyz     int
+	}{
+		{"Running", http.MethodGet, func(l monitor.LoopInfo) monitor.LoopInfo { return l },
+			http.StatusOK, http.StatusOK},
+		{"NotStarted", http.MethodGet, func(l monitor.LoopInfo) monitor.LoopInfo { return monitor.LoopInfo{State: l.State} },
+			http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		{"NoSuccessfulScrape", http.MethodGet, func(l monitor.LoopInfo) monitor.LoopInfo {
+			l.LastSuccessfulScrape = time.Time{}
+			return l
+		}, http.StatusOK, http.StatusServiceUnavailable},
+		{"Frozen", http.MethodGet, func(l monitor.LoopInfo) monitor.LoopInfo {
+			l.LastSuccessfulScrape = time.Time{}
+			l.State = monitor.StateFrozenNetworkOperatesStable.String()
+			return l
+		}, http.StatusOK, http.StatusOK},
+		{"StuckScrape", http.MethodGet, func(l monitor.LoopInfo) monitor.LoopInfo {
+			l.ScrapeStarted = now.Add(-6 * time.Minute)
+			return l
+		}, http.StatusServiceUnavailable, http.StatusOK},
+		{"StuckLoop", http.MethodGet, func(l monitor.LoopInfo) monitor.LoopInfo {
+			l.LastIteration = now.Add(-7 * time.Minute)
+			return l
+		}, http.StatusServiceUnavailable, http.StatusOK},
+		{"HEAD", http.MethodHead, func(l monitor.LoopInfo) monitor.LoopInfo { return monitor.LoopInfo{State: l.State} },
+			http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+	}
+	for _, tc := range tests {
+		t.Run(tc.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockMonitor := monitor.NewMockMonitor(ctrl)
+			mockMonitor.EXPECT().LoopInfo().Times(2).Return(tc.loop(running))
+			netMon := NewNetworkMonitoringService(mockMonitor,
+				WithClock(clocktest.NewFakeClock(now)),
+				WithLivenessTimeout(5*time.Minute),
+			)
+
+			for _, probe := range []struct {
+				handler http.HandlerFunc
+				code    int
+			}{{netMon.Livez, tc.livez}, {netMon.Readyz, tc.readyz}} {
+				w := httptest.NewRecorder()
+				probe.handler(w, httptest.NewRequest(tc.httpMethod, "/", nil))
+				require.Equal(t, probe.code, w.Result().StatusCode)
+				if tc.httpMethod == http.MethodHead {
+					require.Empty(t, w.Body.Bytes())
+					continue
+				}
+				var resp probeResponse
+				require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&resp))
+				require.Equal(t, probe.code == http.StatusOK, resp.Status == "ok")
+				require.Equal(t, probe.code == http.StatusOK, resp.Reason == "")
+			}
+		})
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	netMon := NewNetworkMonitoringService(monitor.NewMockMonitor(ctrl))
+	for _, handler := range []http.HandlerFunc{netMon.Livez, netMon.Readyz} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/", nil))
+		require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+	}
+}
+
+func TestNetworkMonitoringService_NetworkNodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	nodesInfo := monitor.NetworkNodesInfo{
+		Network: monitor.MainNetSchemeChar,
+		Nodes: []monitor.NodeInfo{
+			{Domain: "a", Height: 10, StateHash: "11", Class: monitor.NodeClassValid},
+			{Domain: "b", Height: 0, Class: monitor.NodeClassMalformed, MalformedReason: "invalid height 0"},
+		},
+	}
+	mockMonitor := monitor.NewMockMonitor(ctrl)
+	mockMonitor.EXPECT().NetworkNodesInfo().Times(1).Return(nodesInfo)
+	netMon := NewNetworkMonitoringService(mockMonitor)
+
+	w := httptest.NewRecorder()
+	netMon.NetworkNodes(w, httptest.NewRequest(http.MethodGet, "/nodes", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	require.Equal(t, "application/json", w.Header().Get("content-type"))
+	var actual monitor.NetworkNodesInfo
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&actual))
+	require.Equal(t, nodesInfo, actual)
+
+	w = httptest.NewRecorder()
+	netMon.NetworkNodes(w, httptest.NewRequest(http.MethodPost, "/nodes", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+}
+
+func TestNetworkMonitoringService_NetworkForks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	forksInfo := monitor.NetworkForksInfo{
+		Network:            monitor.MainNetSchemeChar,
+		StateHashCriterion: true,
+		Forks: []monitor.Fork{
+			{
+				Height: 10,
+				Groups: []monitor.ForkGroup{
+					{StateHash: "aa", Weight: 2, Versions: []string{"1.4.2"}, Nodes: []monitor.ForkNode{{Domain: "a", Version: "1.4.2"}, {Domain: "b", Version: "1.4.2"}}},
+					{StateHash: "bb", Weight: 1, Versions: []string{"1.4.0"}, Nodes: []monitor.ForkNode{{Domain: "c", Version: "1.4.0"}}},
+				},
+				MajorityStateHash: "aa",
+				MinorityNodes:     []monitor.ForkNode{{Domain: "c", Version: "1.4.0"}},
+				Duration:          time.Minute,
+			},
+		},
+	}
+	mockMonitor := monitor.NewMockMonitor(ctrl)
+	mockMonitor.EXPECT().NetworkForksInfo().Times(1).Return(forksInfo)
+	netMon := NewNetworkMonitoringService(mockMonitor)
+
+	w := httptest.NewRecorder()
+	netMon.NetworkForks(w, httptest.NewRequest(http.MethodGet, "/forks", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	require.Equal(t, "application/json", w.Header().Get("content-type"))
+	var actual monitor.NetworkForksInfo
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&actual))
+	require.Equal(t, forksInfo, actual)
+
+	w = httptest.NewRecorder()
+	netMon.NetworkForks(w, httptest.NewRequest(http.MethodPost, "/forks", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+}
+
+func TestNetworkMonitoringService_NetworkFlappingNodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	flappingInfo := monitor.NetworkFlappingInfo{
+		Network:   monitor.MainNetSchemeChar,
+		Snapshots: 10,
+		Nodes: []monitor.FlappingNode{
+			{Domain: "a", Transitions: 4, Working: true, Flapping: true},
+			{Domain: "b", Transitions: 1},
+		},
+	}
+	mockMonitor := monitor.NewMockMonitor(ctrl)
+	mockMonitor.EXPECT().NetworkFlappingInfo().Times(1).Return(flappingInfo)
+	netMon := NewNetworkMonitoringService(mockMonitor)
+
+	w := httptest.NewRecorder()
+	netMon.NetworkFlappingNodes(w, httptest.NewRequest(http.MethodGet, "/nodes/flapping", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	require.Equal(t, "application/json", w.Header().Get("content-type"))
+	var actual monitor.NetworkFlappingInfo
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&actual))
+	require.Equal(t, flappingInfo, actual)
+
+	w = httptest.NewRecorder()
+	netMon.NetworkFlappingNodes(w, httptest.NewRequest(http.MethodPost, "/nodes/flapping", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+}
+
+func TestNetworkMonitoringService_NetworkHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	historyInfo := monitor.NetworkHistoryInfo{
+		Updated: now,
+		Network: monitor.MainNetSchemeChar,
+		Points: []monitor.HistoryPoint{
+			{Timestamp: now.Add(-time.Minute), Height: 10, Nodes: 2, WorkingNodes: 2, Criteria: []string{}},
+			{Timestamp: now, Height: 11, Nodes: 2, WorkingNodes: 1, Alerted: true, Criteria: []string{monitor.CriterionNodesDown}},
+		},
+	}
+	mockMonitor := monitor.NewMockMonitor(ctrl)
+	mockMonitor.EXPECT().NetworkHistoryInfo().Times(1).Return(historyInfo)
+	netMon := NewNetworkMonitoringService(mockMonitor)
+
+	w := httptest.NewRecorder()
+	netMon.NetworkHistory(w, httptest.NewRequest(http.MethodGet, "/history", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	require.Equal(t, "application/json", w.Header().Get("content-type"))
+	var actual monitor.NetworkHistoryInfo
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&actual))
+	require.Equal(t, historyInfo, actual)
+
+	w = httptest.NewRecorder()
+	netMon.NetworkHistory(w, httptest.NewRequest(http.MethodPost, "/history", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+}
+
+func TestNetworkMonitoringService_NetworkChain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	chainInfo := monitor.NetworkChainInfo{
+		Network:               monitor.MainNetSchemeChar,
+		Height:                102,
+		ExpectedBlockInterval: time.Minute,
+		Rates: []monitor.BlockRate{
+			{Window: 5 * time.Minute, Span: 5 * time.Minute, Complete: true, Blocks: 4, BlocksPerMinute: 0.8, AvgBlockInterval: 75 * time.Second},
+		},
+	}
+	mockMonitor := monitor.NewMockMonitor(ctrl)
+	mockMonitor.EXPECT().NetworkChainInfo().Times(1).Return(chainInfo)
+	netMon := NewNetworkMonitoringService(mockMonitor)
+
+	w := httptest.NewRecorder()
+	netMon.NetworkChain(w, httptest.NewRequest(http.MethodGet, "/chain", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	require.Equal(t, "application/json", w.Header().Get("content-type"))
+	var actual monitor.NetworkChainInfo
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&actual))
+	require.Equal(t, chainInfo, actual)
+
+	w = httptest.NewRecorder()
+	netMon.NetworkChain(w, httptest.NewRequest(http.MethodPost, "/chain", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+}
+
+func TestNetworkMonitoringService_NetworkBaselines(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	baselinesInfo := monitor.NetworkBaselinesInfo{
+		Network: monitor.MainNetSchemeChar,
+		Enabled: true,
+		Samples: 42,
+		Indicators: []monitor.IndicatorBaseline{
+			{Name: monitor.IndicatorDownNodesPart, Value: 0.5, Mean: 0.1, StdDev: 0.05, Deviation: 8, Anomalous: true},
+		},
+	}
+	mockMonitor := monitor.NewMockMonitor(ctrl)
+	mockMonitor.EXPECT().NetworkBaselinesInfo().Times(1).Return(baselinesInfo)
+	netMon := NewNetworkMonitoringService(mockMonitor)
+
+	w := httptest.NewRecorder()
+	netMon.NetworkBaselines(w, httptest.NewRequest(http.MethodGet, "/baselines", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	require.Equal(t, "application/json", w.Header().Get("content-type"))
+	var actual monitor.NetworkBaselinesInfo
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&actual))
+	require.Equal(t, baselinesInfo, actual)
+
+	w = httptest.NewRecorder()
+	netMon.NetworkBaselines(w, httptest.NewRequest(http.MethodPost, "/baselines", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+}
+
+func TestNetworkMonitoringService_NetworkNodesScores(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scoresInfo := monitor.NetworkNodesScoresInfo{
+		Network: monitor.MainNetSchemeChar,
+		Samples: 10,
+		MaxLag:  monitor.DefaultScoreMaxLag,
+		Nodes: []monitor.NodeScore{
+			{Rank: 1, Domain: "n1", Score: 1, Samples: 10, Uptime: 1},
+			{Rank: 2, Domain: "n2", Score: 0.5, Samples: 10, Uptime: 0.5, MinorityRate: 0.2, Transitions: 3, FlapRate: 0.3},
+		},
+	}
+	mockMonitor := monitor.NewMockMonitor(ctrl)
+	mockMonitor.EXPECT().NetworkNodesScoresInfo().Times(1).Return(scoresInfo)
+	netMon := NewNetworkMonitoringService(mockMonitor)
+
+	w := httptest.NewRecorder()
+	netMon.NetworkNodesScores(w, httptest.NewRequest(http.MethodGet, "/nodes/scores", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	require.Equal(t, "application/json", w.Header().Get("content-type"))
+	var actual monitor.NetworkNodesScoresInfo
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&actual))
+	require.Equal(t, scoresInfo, actual)
+
+	w = httptest.NewRecorder()
+	netMon.NetworkNodesScores(w, httptest.NewRequest(http.MethodPost, "/nodes/scores", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+}
+
+func TestNetworkMonitoringService_NetworkSLA(t *testing.T) {
+	from := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := monitor.SLAReport{
+		Network:       monitor.MainNetSchemeChar,
+		From:          from,
+		To:            to,
+		UptimePercent: 99.5,
+		Operational:   99 * time.Hour,
+		Degraded:      30 * time.Minute,
+		IncidentCount: 1,
+		MTTR:          30 * time.Minute,
+		LongestOutage: 30 * time.Minute,
+		Incidents: []monitor.SLAIncident{
+			{Start: from.Add(time.Hour), End: from.Add(90 * time.Minute), Duration: 30 * time.Minute},
+		},
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockMonitor := monitor.NewMockMonitor(ctrl)
+		mockMonitor.EXPECT().NetworkSLA(from, to).Times(1).Return(report, nil)
+		netMon := NewNetworkMonitoringService(mockMonitor)
+
+		w := httptest.NewRecorder()
+		netMon.NetworkSLA(w, httptest.NewRequest(http.MethodGet, "/sla?from=2021-12-01T00:00:00Z&to=2022-01-01T00:00:00Z", nil))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t, "application/json", w.Header().Get("content-type"))
+		var actual monitor.SLAReport
+		require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&actual))
+		require.Equal(t, report, actual)
+	})
+	t.Run("CSV", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockMonitor := monitor.NewMockMonitor(ctrl)
+		mockMonitor.EXPECT().NetworkSLA(to.Add(-30*24*time.Hour), to).Times(1).Return(report, nil)
+		netMon := NewNetworkMonitoringService(mockMonitor)
+
+		w := httptest.NewRecorder()
+		netMon.NetworkSLA(w, httptest.NewRequest(http.MethodGet, "/sla?to=2022-01-01T00:00:00Z&format=csv", nil))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t, "text/csv", w.Header().Get("content-type"))
+		expected := "network,from,to,uptime_percent,operational_seconds,degraded_seconds,frozen_seconds,unknown_seconds,incident_count,mttr_seconds,longest_outage_seconds\n" +
+			"W,2021-12-01T00:00:00Z,2022-01-01T00:00:00Z,99.5000,356400,1800,0,0,1,1800,1800\n"
+		require.Equal(t, expected, w.Body.String())
+	})
+	t.Run("DefaultPeriod", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockMonitor := monitor.NewMockMonitor(ctrl)
+		mockMonitor.EXPECT().NetworkSLA(to.Add(-30*24*time.Hour), to).Times(1).Return(report, nil)
+		netMon := NewNetworkMonitoringService(mockMonitor, WithClock(clocktest.NewFakeClock(to)))
+
+		w := httptest.NewRecorder()
+		netMon.NetworkSLA(w, httptest.NewRequest(http.MethodGet, "/sla", nil))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+	t.Run("InvalidRequests", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockMonitor := monitor.NewMockMonitor(ctrl)
+		mockMonitor.EXPECT().NetworkSLA(to, from).Times(1).Return(monitor.SLAReport{}, fmt.Errorf("invalid period"))
+		netMon := NewNetworkMonitoringService(mockMonitor)
+
+		for _, target := range []string{
+			"/sla?from=yesterday",
+			"/sla?to=today",
+			"/sla?format=xml",
+			"/sla?from=2022-01-01T00:00:00Z&to=2021-12-01T00:00:00Z",
+		} {
+			w := httptest.NewRecorder()
+			netMon.NetworkSLA(w, httptest.NewRequest(http.MethodGet, target, nil))
+			require.Equal(t, http.StatusBadRequest, w.Result().StatusCode, target)
+		}
+		w := httptest.NewRecorder()
+		netMon.NetworkSLA(w, httptest.NewRequest(http.MethodPost, "/sla", nil))
+		require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+	})
+}
+
+func TestNetworkMonitoringService_NetworkIncidents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	incident := monitor.Incident{
+		ID:           1,
+		Start:        start,
+		End:          start.Add(time.Minute),
+		Duration:     time.Minute,
+		PeakSeverity: monitor.SeverityCritical,
+		Criteria:     []string{monitor.CriterionStateHash},
+		Nodes:        []string{"n1"},
+		StateChanges: []monitor.IncidentStateChange{},
+		Notes:        []monitor.IncidentNote{},
+	}
+	incidentsInfo := monitor.NetworkIncidentsInfo{Network: monitor.MainNetSchemeChar, Incidents: []monitor.Incident{incident}}
+	mockMonitor := monitor.NewMockMonitor(ctrl)
+	mockMonitor.EXPECT().NetworkIncidentsInfo().Times(1).Return(incidentsInfo)
+	mockMonitor.EXPECT().NetworkIncident(1).Times(2).Return(incident, nil)
+	mockMonitor.EXPECT().NetworkIncident(2).Times(1).Return(monitor.Incident{}, monitor.ErrIncidentNotFound)
+	netMon := NewNetworkMonitoringService(mockMonitor)
+
+	w := httptest.NewRecorder()
+	netMon.NetworkIncidents(w, httptest.NewRequest(http.MethodGet, "/incidents", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	require.Equal(t, "application/json", w.Header().Get("content-type"))
+	var actualInfo monitor.NetworkIncidentsInfo
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&actualInfo))
+	require.Equal(t, incidentsInfo, actualInfo)
+
+	w = httptest.NewRecorder()
+	netMon.NetworkIncident(w, httptest.NewRequest(http.MethodGet, "/incidents/1", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	var actual monitor.Incident
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&actual))
+	require.Equal(t, incident, actual)
+	w = httptest.NewRecorder()
+	netMon.NetworkIncident(w, httptest.NewRequest(http.MethodGet, APIV1Prefix+"/incidents/1", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	for _, target := range []string{"/incidents/2", "/incidents/abc", "/incidents/", "/incidents/anything/1",
+		"/incidents/1/notes", "/incidents/1/", "/other/1"} {
+		w = httptest.NewRecorder()
+		netMon.NetworkIncident(w, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusNotFound, w.Result().StatusCode, target)
+	}
+
+	w = httptest.NewRecorder()
+	netMon.NetworkIncidents(w, httptest.NewRequest(http.MethodPost, "/incidents", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+	w = httptest.NewRecorder()
+	netMon.NetworkIncident(w, httptest.NewRequest(http.MethodPost, "/incidents/1", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+}
+
+func TestNetworkMonitoringService_AddIncidentNote(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	note := monitor.IncidentNote{Timestamp: time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC), Author: "ops", Text: "restarted"}
+	incident := monitor.Incident{ID: 1, Criteria: []string{}, Nodes: []string{},
+		StateChanges: []monitor.IncidentStateChange{}, Notes: []monitor.IncidentNote{note},
+	}
+	mockMonitor := monitor.NewMockMonitor(ctrl)
+	mockMonitor.EXPECT().AddIncidentNote(1, "ops", "restarted").Times(1).Return(incident, nil)
+	mockMonitor.EXPECT().AddIncidentNote(2, "", "restarted").Times(1).Return(monitor.Incident{}, monitor.ErrIncidentNotFound)
+	netMon := NewNetworkMonitoringService(mockMonitor)
+
+	w := httptest.NewRecorder()
+	netMon.AddIncidentNote(w, httptest.NewRequest(http.MethodPost, "/incidents/1/notes",
+		strings.NewReader(`{"author":"ops","text":"restarted"}`),
+	))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	var actual monitor.Incident
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&actual))
+	require.Equal(t, incident, actual)
+
+	tests := []struct {
+		method string
+		target string
+		body   string
+		code   int
+	}{
+		{http.MethodPost, "/incidents/2/notes", `{"text":"restarted"}`, http.StatusNotFound},
+		{http.MethodPost, "/incidents/abc/notes", `{"text":"restarted"}`, http.StatusNotFound},
+		{http.MethodPost, "/incidents/anything/1/notes", `{"text":"restarted"}`, http.StatusNotFound},
+		{http.MethodPost, "/incidents/notes", `{"text":"restarted"}`, http.StatusNotFound},
+		{http.MethodPost, "/incidents/1/notes", `{"text":" "}`, http.StatusBadRequest},
+		{http.MethodPost, "/incidents/1/notes", `{"text":`, http.StatusBadRequest},
+		{http.MethodGet, "/incidents/1/notes", ``, http.StatusMethodNotAllowed},
+	}
+	for _, tc := range tests {
+		w = httptest.NewRecorder()
+		netMon.AddIncidentNote(w, httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+		require.Equal(t, tc.code, w.Result().StatusCode, tc.target+" "+tc.body)
+	}
+}
+
 func TestNetworkMonitoringService_SetMonitorState(t *testing.T) {
 	tests := []struct {
 		testName        string
@@ -0,0 +1,107 @@
+package service
+
+import (
+	_ "embed"
+	"net/http"
+	"strings"
+)
+
+// APIV1Prefix is the path prefix of the versioned API, all routes are also served without it for compatibility.
+const APIV1Prefix = "/api/v1"
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+type route struct {
+	path    string
+	handler http.Handler
+	private bool // route is protected by the auth middleware
+}
+
+func (s *NetworkMonitoringService) routes() []route {
+	return []route{
+		{path: "/health", handler: http.HandlerFunc(s.NetworkHealth)},
+		{path: "/health/status", handler: http.HandlerFunc(s.NetworkHealthStatus)},
+		{path: "/livez", handler: http.HandlerFunc(s.Livez)},
+		{path: "/readyz", handler: http.HandlerFunc(s.Readyz)},
+		{path: "/nodes", handler: http.HandlerFunc(s.NetworkNodes)},
+		{path: "/nodes/flapping", handler: http.HandlerFunc(s.NetworkFlappingNodes)},
+		{path: "/nodes/scores", handler: http.HandlerFunc(s.NetworkNodesScores)},
+		{path: "/forks", handler: http.HandlerFunc(s.NetworkForks)},
+		{path: "/history", handler: http.HandlerFunc(s.NetworkHistory)},
+		{path: "/chain", handler: http.HandlerFunc(s.NetworkChain)},
+		{path: "/baselines", handler: http.HandlerFunc(s.NetworkBaselines)},
+		{path: "/sla", handler: http.HandlerFunc(s.NetworkSLA)},
+		{path: "/incidents", handler: http.HandlerFunc(s.NetworkIncidents)},
+		{path: "/incidents/{id}", handler: http.HandlerFunc(s.NetworkIncident)},
+		{path: "/debug/vars", handler: http.HandlerFunc(DebugVars)},
+		// private URLs
+		{path: "/state", handler: http.HandlerFunc(s.SetMonitorState), private: true},
+		{path: "/incidents/{id}" + incidentNotesSuffix, handler: http.HandlerFunc(s.AddIncidentNote), private: true},
+	}
+}
+
+// pathIDParam is the path parameter of routes like /incidents/{id}, handlers parse it from the request path.
+const pathIDParam = "{id}"
+
+// NewRouter returns the handler of all service routes. Each route is served under APIV1Prefix and by its legacy path.
+// Private routes are wrapped with the auth middleware. Unknown paths are answered with the JSON 404 error.
+func NewRouter(s *NetworkMonitoringService, authMiddleware func(next http.Handler) http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	// handlers of routes with pathIDParam by the path suffix after it, grouped by the path prefix before it
+	subtrees := make(map[string]map[string]http.Handler)
+	for _, r := range s.routes() {
+		handler := r.handler
+		if r.private {
+			handler = authMiddleware(handler)
+		}
+		if prefix, suffix, ok := strings.Cut(r.path, pathIDParam); ok {
+			if subtrees[prefix] == nil {
+				subtrees[prefix] = make(map[string]http.Handler)
+			}
+			subtrees[prefix][suffix] = handler
+			continue
+		}
+		mux.Handle(APIV1Prefix+r.path, handler)
+		mux.Handle(r.path, handler)
+	}
+	for prefix, handlers := range subtrees {
+		handler := subtreeHandler(handlers)
+		mux.Handle(APIV1Prefix+prefix, handler)
+		mux.Handle(prefix, handler)
+	}
+	mux.HandleFunc(APIV1Prefix+"/openapi.json", OpenAPI)
+	mux.HandleFunc("/dashboard", s.Dashboard)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeStatusError(w, http.StatusNotFound)
+	})
+	return mux
+}
+
+// subtreeHandler dispatches the request to the handler with the longest matching path suffix. The handler with the
+// empty suffix is the fallback one.
+func subtreeHandler(handlers map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, matched := handlers[""], ""
+		for suffix, h := range handlers {
+			if len(suffix) > len(matched) && strings.HasSuffix(r.URL.Path, suffix) {
+				handler, matched = h, suffix
+			}
+		}
+		if handler == nil {
+			writeStatusError(w, http.StatusNotFound)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// OpenAPI serves the OpenAPI document of the versioned API.
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatusError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	_, _ = w.Write(openAPIDocument)
+}
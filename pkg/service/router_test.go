@@ -0,0 +1,121 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nickeskov/netmon/pkg/monitor"
+	"github.com/nickeskov/netmon/pkg/service/middleware"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRouter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMonitor := monitor.NewMockMonitor(ctrl)
+	nodesInfo := monitor.NetworkNodesInfo{Network: monitor.MainNetSchemeChar, Nodes: []monitor.NodeInfo{}}
+	mockMonitor.EXPECT().NetworkNodesInfo().Times(2).Return(nodesInfo)
+	mockMonitor.EXPECT().ChangeState(monitor.StateFrozenNetworkDegraded).Times(2).Return(monitor.StateActive)
+	incident := monitor.Incident{ID: 1, Criteria: []string{}, Nodes: []string{},
+		StateChanges: []monitor.IncidentStateChange{}, Notes: []monitor.IncidentNote{},
+	}
+	mockMonitor.EXPECT().NetworkIncident(1).Times(2).Return(incident, nil)
+	mockMonitor.EXPECT().AddIncidentNote(1, "", "restarted").Times(2).Return(incident, nil)
+	netMon := NewNetworkMonitoringService(mockMonitor)
+	router := NewRouter(&netMon, middleware.NewHTTPAuthTokenMiddleware("auth", "token"))
+
+	serve := func(method, path, token string, body string) *http.Response {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			r.Header.Set("auth", token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Result()
+	}
+	requireError := func(resp *http.Response, code int) {
+		require.Equal(t, code, resp.StatusCode)
+		require.Equal(t, "application/json", resp.Header.Get("content-type"))
+		var actual ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&actual))
+		require.Equal(t, ErrorResponse{Code: code, Error: http.StatusText(code)}, actual)
+	}
+
+	for _, path := range []string{"/nodes", APIV1Prefix + "/nodes"} {
+		resp := serve(http.MethodGet, path, "", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode, path)
+		var actual monitor.NetworkNodesInfo
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&actual))
+		require.Equal(t, nodesInfo, actual)
+
+		requireError(serve(http.MethodPost, path, "", ""), http.StatusMethodNotAllowed)
+	}
+	for _, path := range []string{"/state", APIV1Prefix + "/state"} {
+		requireError(serve(http.MethodPost, path, "", `{"state":"frozen_degraded"}`), http.StatusForbidden)
+		requireError(serve(http.MethodPost, path, "invalid", `{"state":"frozen_degraded"}`), http.StatusForbidden)
+		requireError(serve(http.MethodPost, path, "token", `{"state":"invalid"}`), http.StatusBadRequest)
+		require.Equal(t, http.StatusOK, serve(http.MethodPost, path, "token", `{"state":"frozen_degraded"}`).StatusCode)
+	}
+	for _, prefix := range []string{"", APIV1Prefix} {
+		path := prefix + "/incidents/1"
+		resp := serve(http.MethodGet, path, "", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode, path)
+		var actual monitor.Incident
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&actual))
+		require.Equal(t, incident, actual)
+		requireError(serve(http.MethodGet, prefix+"/incidents/anything/1", "", ""), http.StatusNotFound)
+
+		path = prefix + "/incidents/1/notes"
+		requireError(serve(http.MethodPost, path, "", `{"text":"restarted"}`), http.StatusForbidden)
+		requireError(serve(http.MethodPost, path, "token", `{"text":" "}`), http.StatusBadRequest)
+		require.Equal(t, http.StatusOK, serve(http.MethodPost, path, "token", `{"text":"restarted"}`).StatusCode)
+		requireError(serve(http.MethodPost, prefix+"/incidents/anything/1/notes", "token", `{"text":"restarted"}`),
+			http.StatusNotFound)
+	}
+	require.Equal(t, http.StatusOK, serve(http.MethodGet, "/dashboard", "", "").StatusCode)
+	for _, path := range []string{"/debug/vars", APIV1Prefix + "/debug/vars"} {
+		resp := serve(http.MethodGet, path, "", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode, path)
+		vars := map[string]json.RawMessage{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&vars))
+		require.Contains(t, vars, monitor.MetricsPrefix+"chain_height")
+		for name := range vars {
+			require.True(t, strings.HasPrefix(name, monitor.MetricsPrefix), name)
+		}
+	}
+	requireError(serve(http.MethodGet, "/unknown", "", ""), http.StatusNotFound)
+	requireError(serve(http.MethodGet, APIV1Prefix+"/unknown", "", ""), http.StatusNotFound)
+}
+
+func TestOpenAPI(t *testing.T) {
+	w := httptest.NewRecorder()
+	OpenAPI(w, httptest.NewRequest(http.MethodGet, APIV1Prefix+"/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	require.Equal(t, "application/json", w.Result().Header.Get("content-type"))
+
+	var doc struct {
+		OpenAPI string                                `json:"openapi"`
+		Servers []struct{ URL string }                `json:"servers"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&doc))
+	require.Equal(t, APIV1Prefix, doc.Servers[0].URL)
+
+	// every route is documented with all its methods
+	netMon := NewNetworkMonitoringService(nil)
+	routes := netMon.routes()
+	require.Len(t, doc.Paths, len(routes)+1) // routes and the document itself
+	for _, r := range routes {
+		require.Contains(t, doc.Paths, r.path)
+		require.NotEmpty(t, doc.Paths[r.path], r.path)
+	}
+
+	w = httptest.NewRecorder()
+	OpenAPI(w, httptest.NewRequest(http.MethodPost, APIV1Prefix+"/openapi.json", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+}